@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+// annotationRe matches an annotation comment that pandoctor leaves above a table it could not process.
+var annotationRe = regexp.MustCompile(`^<!-- pandoctor: could not ([a-z ]+): .* -->$`)
+
+// annotationVerb returns the verb used in annotations for the given action, e.g. "convert" for "convert_tables" or
+// "drop column" for "drop_column".
+func annotationVerb(action string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(action, "_tables"), "_", " ")
+}
+
+// annotation returns the annotation comment for a table that could not be processed by the given action, followed by
+// a blank line. The position of a gridtable.TableError is left out, since inserting the annotation moves the table.
+func annotation(action string, err error) []byte {
+	var tableErr *gridtable.TableError
+	if errors.As(err, &tableErr) {
+		err = tableErr.Err
+	}
+	msg := strings.Join(strings.Fields(err.Error()), " ")
+	// HTML comments can't contain "--".
+	for strings.Contains(msg, "--") {
+		msg = strings.ReplaceAll(msg, "--", "- -")
+	}
+	return []byte(fmt.Sprintf("<!-- pandoctor: could not %v: %v -->\n\n", annotationVerb(action), msg))
+}
+
+// splitAnnotations splits the text before a table into the text before the annotations left on the table by previous
+// runs, the annotations left by other actions, and those left by the given action (each including the blank lines
+// after it). The lines of the annotations start with prefix, like those of the table.
+func splitAnnotations(before []byte, action string, prefix string) (rest, others, ours []byte) {
+	end := len(before)
+	var otherRuns, ourRuns [][]byte
+	for {
+		trimmed := trimBlankLines(before[:end], prefix)
+		start := bytes.LastIndexByte(trimmed, '\n') + 1
+		m := annotationRe.FindStringSubmatch(gridtable.StripPrefix(string(trimmed[start:]), prefix))
+		if m == nil {
+			break
+		}
+		if m[1] == annotationVerb(action) {
+			ourRuns = append(ourRuns, before[start:end])
+		} else {
+			otherRuns = append(otherRuns, before[start:end])
+		}
+		end = start
+	}
+	// The annotations were found from the bottom up.
+	slices.Reverse(otherRuns)
+	slices.Reverse(ourRuns)
+	return before[:end], bytes.Join(otherRuns, nil), bytes.Join(ourRuns, nil)
+}
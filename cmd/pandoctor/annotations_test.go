@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const annotatedDoc = `# Registers
+
+Table: Registers
+
++--------+--------+
+| Offset | Name   |
++========+========+
+| 0x00   | CTRL   |
++--------+--------+
+`
+
+// runAction runs processTables with an action that fails with err, or succeeds without changing the table if err is
+// nil.
+func runAction(contents string, action string, err error) string {
+	return string(processTables([]byte(contents), action, findGridTables([]byte(contents)), func(contents []byte, loc tableBlock) ([]byte, string, error) {
+		if err != nil {
+			return nil, "", err
+		}
+		return loc.text(contents), statusUnchanged, nil
+	}))
+}
+
+func TestAnnotationsRunTwice(t *testing.T) {
+	malformed := strings.Replace(annotatedDoc, "| 0x00   | CTRL   |", "| 0x00   |", 1)
+	first, err := fmtTables([]byte(malformed))
+	if err != nil {
+		t.Fatalf("fmtTables() = %v", err)
+	}
+	if !strings.Contains(string(first), "<!-- pandoctor: could not fmt: malformed grid table") {
+		t.Fatalf("fmtTables() didn't annotate the table:\n%v", string(first))
+	}
+	if regexp.MustCompile(`line \d`).Match(first) {
+		t.Errorf("fmtTables() annotation has a position:\n%v", string(first))
+	}
+	second, err := fmtTables(first)
+	if err != nil {
+		t.Fatalf("fmtTables() = %v", err)
+	}
+	if diff := cmp.Diff(string(first), string(second)); diff != "" {
+		t.Errorf("second fmtTables() diff (-first +second)\n%v", diff)
+	}
+}
+
+func TestAnnotationsMixedActions(t *testing.T) {
+	table := annotatedDoc[strings.Index(annotatedDoc, "Table:"):]
+	for _, tc := range []struct {
+		name  string
+		steps []struct {
+			action string
+			err    error
+		}
+		want string
+	}{
+		{
+			name: "new error replaces old",
+			steps: []struct {
+				action string
+				err    error
+			}{
+				{"fmt_tables", errors.New("malformed grid table")},
+				{"fmt_tables", errors.New("content no longer fits")},
+			},
+			want: "<!-- pandoctor: could not fmt: content no longer fits -->\n\n",
+		},
+		{
+			name: "annotations of other actions are kept",
+			steps: []struct {
+				action string
+				err    error
+			}{
+				{"resize_tables", errors.New("first")},
+				{"repair_tables", errors.New("second")},
+				{"resize_tables", errors.New("third")},
+			},
+			want: "<!-- pandoctor: could not repair: second -->\n\n<!-- pandoctor: could not resize: third -->\n\n",
+		},
+		{
+			name: "success removes only the action's annotation",
+			steps: []struct {
+				action string
+				err    error
+			}{
+				{"resize_tables", errors.New("first")},
+				{"repair_tables", errors.New("second")},
+				{"resize_tables", nil},
+			},
+			want: "<!-- pandoctor: could not repair: second -->\n\n",
+		},
+		{
+			name: "all annotations removed",
+			steps: []struct {
+				action string
+				err    error
+			}{
+				{"resize_tables", errors.New("first")},
+				{"repair_tables", errors.New("second")},
+				{"resize_tables", nil},
+				{"repair_tables", nil},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := annotatedDoc
+			for _, step := range tc.steps {
+				got = runAction(got, step.action, step.err)
+			}
+			want := strings.Replace(annotatedDoc, table, tc.want+table, 1)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("diff (-want +got)\n%v", diff)
+			}
+			// Running the last step again doesn't change anything.
+			last := tc.steps[len(tc.steps)-1]
+			if diff := cmp.Diff(got, runAction(got, last.action, last.err)); diff != "" {
+				t.Errorf("rerun diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
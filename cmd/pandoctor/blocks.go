@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	wrapCaptions = flag.Bool("wrap_captions", false, "set to re-wrap the captions of the tables that are written to the width of the table")
+)
+
+// gridTableRe matches a grid table. Each line may be indented or prefixed with block quote markers.
+var gridTableRe = regexp.MustCompile("(?m)^[ \t>]*\\+[\\-\\+]+\n([ \t>]*[|\\+].*\n)*[ \t>]*\\+[\\-=\\+]+\n")
+
+// lenientGridTableRe matches a grid table that may have trailing whitespace after its separators.
+var lenientGridTableRe = regexp.MustCompile("(?m)^[ \t>]*\\+[\\-\\+]+[ \t]*\n([ \t>]*[|\\+].*\n)*[ \t>]*\\+[\\-=\\+]+[ \t]*\n")
+
+// A tableBlock is the location of a table, and its caption paragraph (if any), within a document.
+type tableBlock struct {
+	// The whole block, including the caption paragraph, is contents[start:end].
+	start, end int
+	// The table itself is contents[tableStart:tableEnd].
+	tableStart, tableEnd int
+}
+
+// findGridTables returns the locations of the grid tables in the document.
+// Like Pandoc, a caption paragraph between two tables belongs to the first table, unless the first table already has
+// a caption before it.
+func findGridTables(contents []byte) []tableBlock {
+	return findTables(contents, gridTableRe)
+}
+
+// findTables returns the locations of the tables matched by tableRe in the document, with their captions.
+func findTables(contents []byte, tableRe *regexp.Regexp) []tableBlock {
+	blocks := matchTables(contents, tableRe)
+	addCaptions(contents, blocks, func(int) bool { return true })
+	return blocks
+}
+
+// matchTables returns the locations of the tables matched by tableRe in the document, without their captions. Tables
+// in fenced code blocks are only examples, so they aren't included.
+func matchTables(contents []byte, tableRe *regexp.Regexp) []tableBlock {
+	var result []tableBlock
+	codeBlocks := findCodeBlocks(contents)
+	// Skip any YAML metadata block at the start of the document.
+	start := frontMatterEnd(contents)
+	for _, loc := range tableRe.FindAllIndex(contents[start:], -1) {
+		inCodeBlock := slices.ContainsFunc(codeBlocks, func(code [2]int) bool {
+			return code[0] <= start+loc[0] && start+loc[0] < code[1]
+		})
+		if inCodeBlock {
+			continue
+		}
+		result = append(result, tableBlock{
+			start:      start + loc[0],
+			end:        start + loc[1],
+			tableStart: start + loc[0],
+			tableEnd:   start + loc[1],
+		})
+	}
+	return result
+}
+
+// codeFenceRe matches the opening line of a fenced code block.
+var codeFenceRe = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// findCodeBlocks returns the [start, end) offsets of the fenced code blocks in the document.
+func findCodeBlocks(contents []byte) [][2]int {
+	var result [][2]int
+	for offset := frontMatterEnd(contents); offset < len(contents); {
+		line, next := nextLine(contents, offset)
+		if m := codeFenceRe.FindSubmatch(line); m != nil {
+			end := closingFence(contents, next, m[1])
+			result = append(result, [2]int{offset, end})
+			next = end
+		}
+		offset = next
+	}
+	return result
+}
+
+// addCaptions extends the tables, which are in order, to include their caption paragraphs. Only the tables for which
+// captioned returns true can have a caption, but the others still keep a paragraph from being the caption of a table
+// on the other side of them.
+func addCaptions(contents []byte, blocks []tableBlock, captioned func(i int) bool) {
+	prevEnd := frontMatterEnd(contents)
+	for n := range blocks {
+		block := &blocks[n]
+		if !captioned(n) {
+			prevEnd = block.end
+			continue
+		}
+		prefix := block.prefix(contents)
+		if start, ok := captionBefore(contents[prevEnd:block.tableStart], prefix); ok {
+			block.start = prevEnd + start
+		} else {
+			nextStart := len(contents)
+			if n+1 < len(blocks) {
+				nextStart = blocks[n+1].tableStart
+			}
+			if end, ok := captionAfter(contents[block.tableEnd:nextStart], prefix); ok {
+				block.end = block.tableEnd + end
+			}
+		}
+		prevEnd = block.end
+	}
+}
+
+// captionBefore looks for a caption paragraph at the end of text, whose lines start with prefix. It returns the offset
+// of the start of the paragraph and whether it was found.
+func captionBefore(text []byte, prefix string) (int, bool) {
+	lines := strings.Split(string(text), "\n")
+	end := len(lines)
+	for end > 0 && isBlankLine(lines[end-1], prefix) {
+		end--
+	}
+	if end == 0 {
+		return 0, false
+	}
+	start := end
+	for start > 0 && !isBlankLine(lines[start-1], prefix) {
+		start--
+	}
+	if !isCaptionParagraph(lines[start:end], prefix) {
+		return 0, false
+	}
+	offset := 0
+	for _, line := range lines[:start] {
+		offset += len(line) + 1
+	}
+	return offset, true
+}
+
+// captionAfter looks for a caption paragraph at the start of text, whose lines start with prefix. It returns the
+// offset of the end of the paragraph (including its trailing newline) and whether it was found.
+func captionAfter(text []byte, prefix string) (int, bool) {
+	lines := strings.Split(string(text), "\n")
+	start := 0
+	for start < len(lines) && isBlankLine(lines[start], prefix) {
+		start++
+	}
+	end := start
+	for end < len(lines) && !isBlankLine(lines[end], prefix) {
+		end++
+	}
+	if start == end || !isCaptionParagraph(lines[start:end], prefix) {
+		return 0, false
+	}
+	offset := 0
+	for _, line := range lines[:end] {
+		offset += len(line) + 1
+	}
+	return min(offset, len(text)), true
+}
+
+// isBlankLine returns whether the line is blank apart from prefix (or the start of it).
+func isBlankLine(line string, prefix string) bool {
+	return strings.TrimSpace(gridtable.StripPrefix(line, prefix)) == ""
+}
+
+// isCaptionParagraph returns whether the lines, which start with prefix, are a caption paragraph.
+func isCaptionParagraph(lines []string, prefix string) bool {
+	return gridtable.IsCaption(strings.TrimSpace(gridtable.StripPrefix(strings.Join(lines, "\n"), prefix)))
+}
+
+// trimBlankLines removes the blank lines (apart from prefix) and trailing whitespace from the end of text.
+func trimBlankLines(text []byte, prefix string) []byte {
+	for {
+		text = bytes.TrimRight(text, " \t\n")
+		start := bytes.LastIndexByte(text, '\n') + 1
+		if len(text) == 0 || !isBlankLine(string(text[start:]), prefix) {
+			return text
+		}
+		text = text[:start]
+	}
+}
+
+// prefix returns the indentation and block quote markers at the start of each line of the table, e.g. "> " for a table
+// in a block quote.
+func (b tableBlock) prefix(contents []byte) string {
+	line, _ := nextLine(contents, b.tableStart)
+	return gridtable.LinePrefix(string(line))
+}
+
+// text returns the text of the whole block.
+func (b tableBlock) text(contents []byte) []byte {
+	return contents[b.start:b.end]
+}
+
+// withTable returns the text of the whole block, with the table replaced by table.
+func (b tableBlock) withTable(contents []byte, table []byte) []byte {
+	var result []byte
+	result = append(result, contents[b.start:b.tableStart]...)
+	result = append(result, table...)
+	result = append(result, contents[b.tableEnd:b.end]...)
+	return result
+}
+
+// processTables calls process on each of the given tables in the document and replaces the table with the result.
+// process returns the new text for the whole block and the status of the table, or an error if the table could not be
+// processed. In that case, the table is left as-is and annotated with the error, unless --ignore_errors is set.
+// Annotations left on the tables by previous runs of the same action are removed or refreshed, and those left by other
+// actions are kept. Tables that don't overlap the lines changed since --changed_since (if set), that match an ignore
+// rule or that have a skip option are skipped.
+func processTables(contents []byte, action string, blocks []tableBlock, process func(contents []byte, block tableBlock) ([]byte, string, error)) []byte {
+	var result bytes.Buffer
+	last := 0
+	for _, block := range blocks {
+		prefix := block.prefix(contents)
+		before, otherAnnotations, oldAnnotation := splitAnnotations(contents[last:block.start], action, prefix)
+		result.Write(before)
+		result.Write(otherAnnotations)
+		opts, err := tableOptionsAt(contents, block)
+		if err == nil && (opts.skip || !touched(contents, block) || ignored(contents, block)) {
+			recordTable(contents, block.tableStart, block.tableEnd, action, statusSkipped, nil)
+			result.Write(oldAnnotation)
+			result.Write(block.text(contents))
+			last = block.end
+			continue
+		}
+		var replacement []byte
+		var status string
+		if err == nil {
+			replacement, status, err = process(contents, block)
+		}
+		if err != nil {
+			err = tableErrorInFile(contents, block.tableStart, err)
+			recordTable(contents, block.tableStart, block.tableEnd, action, statusFailed, err)
+			if *ignoreErrors {
+				result.Write(oldAnnotation)
+			} else {
+				reportTableError(contents, block.tableStart, err)
+				result.WriteString(gridtable.AddPrefix(string(annotation(action, err)), prefix))
+			}
+			result.Write(block.text(contents))
+		} else {
+			recordTable(contents, block.tableStart, block.tableEnd, action, status, nil)
+			result.Write(replacement)
+		}
+		last = block.end
+	}
+	result.Write(contents[last:])
+	return result.Bytes()
+}
+
+// formatBlock renders a table block, re-wrapping the caption if requested.
+// If the caption doesn't need to be re-wrapped, only the table within the original block is replaced.
+func formatBlock(contents []byte, loc tableBlock, block *gridtable.Block) ([]byte, error) {
+	if *wrapCaptions {
+		result, err := block.Format(block.Width())
+		return []byte(result), err
+	}
+	table, err := block.TableString()
+	if err != nil {
+		return nil, err
+	}
+	return loc.withTable(contents, []byte(table)), nil
+}
+
+// renderBlock renders a table block with formatBlock, and reports whether that changed it.
+func renderBlock(contents []byte, loc tableBlock, block *gridtable.Block) ([]byte, string, error) {
+	result, err := formatBlock(contents, loc, block)
+	if err != nil {
+		return nil, "", err
+	}
+	if bytes.Equal(result, loc.text(contents)) {
+		return result, statusUnchanged, nil
+	}
+	return result, statusChanged, nil
+}
+
+// lineNumber returns the (1-based) line number of the given offset within contents.
+func lineNumber(contents []byte, offset int) int {
+	return bytes.Count(contents[:offset], []byte("\n")) + 1
+}
+
+// tableErrorInFile converts the position of a gridtable.TableError from a position within the table starting at
+// contents[tableStart] to a position within the file. Other errors are returned unchanged.
+func tableErrorInFile(contents []byte, tableStart int, err error) error {
+	var tableErr *gridtable.TableError
+	if !errors.As(err, &tableErr) || tableErr.Line == 0 {
+		return err
+	}
+	fileErr := *tableErr
+	fileErr.Line += lineNumber(contents, tableStart) - 1
+	return &fileErr
+}
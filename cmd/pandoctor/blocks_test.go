@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTablesInCodeBlocks(t *testing.T) {
+	const codeBlocks = "```markdown\n" + `+---+----+
+| A | B  |
++===+====+
+| 1 |  2 |
++---+----+
+` + "```\n\n~~~\n" + `<table>
+<tr><td>C</td></tr>
+</table>
+` + "~~~\n\n"
+	for _, tc := range []struct {
+		action   string
+		run      func([]byte) ([]byte, error)
+		contents string
+		want     string
+	}{
+		{
+			action: "fmt_tables",
+			run:    fmtTables,
+			contents: codeBlocks + `+---+----+
+| A | B  |
++===+====+
+| 1 |  2 |
++---+----+
+`,
+			want: codeBlocks + `+---+----+
+| A | B  |
++===+====+
+| 1 | 2  |
++---+----+
+`,
+		},
+		{
+			action:   "convert_tables",
+			run:      convertTables,
+			contents: codeBlocks,
+			want:     codeBlocks,
+		},
+	} {
+		t.Run(tc.action, func(t *testing.T) {
+			got, err := tc.run([]byte(tc.contents))
+			if err != nil {
+				t.Fatalf("%v = %v", tc.action, err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("%v diff (-want +got)\n%v", tc.action, diff)
+			}
+		})
+	}
+}
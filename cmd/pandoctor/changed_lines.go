@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	changedSince = flag.String("changed_since", "", "set to a git revision (e.g. HEAD) to only process the tables that overlap lines of --file changed since that revision")
+)
+
+// hunkHeaderRe matches the header of a hunk of a unified diff, capturing the number of lines in the old file and the
+// range of lines in the new file.
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// A lineRange is an inclusive range of (1-based) line numbers.
+type lineRange struct {
+	first, last int
+}
+
+// changedLines are the lines of --file changed since --changed_since, if it is set.
+var changedLines []lineRange
+
+// loadChangedLines asks git which lines of --file changed since --changed_since, if it is set.
+func loadChangedLines() error {
+	if *changedSince == "" {
+		return nil
+	}
+	cmd := exec.Command("git", "diff", "--no-color", "--no-ext-diff", "--unified=0", "--relative", *changedSince, "--", filepath.Base(*file))
+	cmd.Dir = filepath.Dir(*file)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("--changed_since: git diff: %v", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return fmt.Errorf("--changed_since: %w", err)
+	}
+	changedLines = parseChangedLines(string(out), filepath.Base(*file))
+	return nil
+}
+
+// parseChangedLines returns the lines of the file at path changed by a unified diff, which may also change other files.
+// Where lines were only removed, the lines on either side of them count as changed.
+func parseChangedLines(diff string, path string) []lineRange {
+	var result []lineRange
+	inFile := false
+	// The lines of the old and new files left in the current hunk.
+	oldLeft, newLeft := 0, 0
+	for _, line := range strings.Split(diff, "\n") {
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "+++ "); ok {
+			inFile = diffPath(name) == path
+			continue
+		}
+		m := hunkHeaderRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		oldLeft, newLeft = hunkCount(m[1]), hunkCount(m[3])
+		if !inFile {
+			continue
+		}
+		start, _ := strconv.Atoi(m[2])
+		if newLeft == 0 {
+			result = append(result, lineRange{start, start + 1})
+		} else {
+			result = append(result, lineRange{start, start + newLeft - 1})
+		}
+	}
+	return result
+}
+
+// hunkCount returns the number of lines in a range of a hunk header, which is 1 if it is omitted.
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// diffPath returns the path of a file in the "+++" line of a diff, without git's "b/" prefix. Git quotes paths with
+// unusual characters.
+func diffPath(name string) string {
+	name = strings.TrimSuffix(name, "\t")
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+	return strings.TrimPrefix(name, "b/")
+}
+
+// touched returns whether the block overlaps any lines changed since --changed_since, or true if it isn't set.
+func touched(contents []byte, block tableBlock) bool {
+	if *changedSince == "" {
+		return true
+	}
+	first, last := lineNumber(contents, block.start), lineNumber(contents, block.end-1)
+	for _, r := range changedLines {
+		if r.first <= last && first <= r.last {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseChangedLines(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		diff string
+		want []lineRange
+	}{
+		{
+			name: "no changes",
+		},
+		{
+			name: "changed lines",
+			diff: `diff --git a/doc.md b/doc.md
+index 1111111..2222222 100644
+--- a/doc.md
++++ b/doc.md
+@@ -3,2 +3,3 @@ Intro
+-old
+-old
++new
++new
++new
+`,
+			want: []lineRange{{3, 5}},
+		},
+		{
+			name: "removed lines",
+			diff: `--- a/doc.md
++++ b/doc.md
+@@ -5,2 +4,0 @@
+-gone
+-gone
+`,
+			want: []lineRange{{4, 5}},
+		},
+		{
+			name: "single lines without counts",
+			diff: `--- a/doc.md
++++ b/doc.md
+@@ -2 +2 @@
+-old
++new
+@@ -9,0 +10 @@
++added
+@@ -20 +20,0 @@
+-gone
+`,
+			want: []lineRange{{2, 2}, {10, 10}, {20, 21}},
+		},
+		{
+			name: "multiple files",
+			diff: `diff --git a/other.md b/other.md
+--- a/other.md
++++ b/other.md
+@@ -1,2 +1,2 @@
+-a
+-b
++c
++d
+diff --git a/doc.md b/doc.md
+--- a/doc.md
++++ b/doc.md
+@@ -7 +7 @@
+-old
++new
+diff --git a/docs/doc.md b/docs/doc.md
+--- a/docs/doc.md
++++ b/docs/doc.md
+@@ -30 +30 @@
+-old
++new
+`,
+			want: []lineRange{{7, 7}},
+		},
+		{
+			name: "renamed file",
+			diff: `diff --git a/old.md b/doc.md
+similarity index 90%
+rename from old.md
+rename to doc.md
+--- a/old.md
++++ b/doc.md
+@@ -12,0 +13,2 @@
++new
++new
+`,
+			want: []lineRange{{13, 14}},
+		},
+		{
+			name: "renamed away",
+			diff: `diff --git a/doc.md b/new.md
+rename from doc.md
+rename to new.md
+--- a/doc.md
++++ b/new.md
+@@ -1 +1 @@
+-old
++new
+`,
+		},
+		{
+			name: "lines that look like headers",
+			diff: `--- a/doc.md
++++ b/doc.md
+@@ -1,2 +1,2 @@
+--- a/other.md
+-@@ -1 +1 @@
++++ b/other.md
++@@ -50 +50 @@
+@@ -8 +8 @@
+-old
++new
+`,
+			want: []lineRange{{1, 2}, {8, 8}},
+		},
+		{
+			name: "no newline at end of file",
+			diff: `--- a/doc.md
++++ b/doc.md
+@@ -3 +3 @@
+-old
+\ No newline at end of file
++new
+\ No newline at end of file
+@@ -9 +9 @@
+-old
++new
+`,
+			want: []lineRange{{3, 3}, {9, 9}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseChangedLines(tc.diff, "doc.md")
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(lineRange{})); diff != "" {
+				t.Errorf("parseChangedLines() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestParseChangedLinesQuotedPath(t *testing.T) {
+	diff := `--- "a/caf\303\251.md"
++++ "b/caf\303\251.md"
+@@ -4 +4 @@
+-old
++new
+`
+	want := []lineRange{{4, 4}}
+	if diff := cmp.Diff(want, parseChangedLines(diff, "café.md"), cmp.AllowUnexported(lineRange{})); diff != "" {
+		t.Errorf("parseChangedLines() diff (-want +got)\n%v", diff)
+	}
+}
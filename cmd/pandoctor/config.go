@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	configFile = flag.String("config", "", "project configuration file (default: nearest .pandoctor.yaml or .pandoctor.json above --file, or the current directory)")
+)
+
+// configFileNames are the names of the project configuration files that are discovered automatically, in order of
+// preference within a single directory.
+var configFileNames = []string{".pandoctor.yaml", ".pandoctor.yml", ".pandoctor.json"}
+
+// projectConfig is the contents of a project configuration file.
+// Since JSON is a subset of YAML, the same struct is used to decode both formats.
+type projectConfig struct {
+	// Default for --table_width.
+	TableWidth *int `yaml:"table_width"`
+	// Default for --ignore_errors.
+	IgnoreErrors *bool `yaml:"ignore_errors"`
+	// Rules applied by resize_tables, in order. The first rule that matches a table is used.
+	ResizeRules []resizeRule `yaml:"resize_rules"`
+	// Tables that actions leave alone.
+	Ignore []tableMatcher `yaml:"ignore"`
+}
+
+// validate checks and compiles the rules in the configuration, which came from source.
+func (config *projectConfig) validate(source string) error {
+	for i := range config.ResizeRules {
+		if err := config.ResizeRules[i].validate(); err != nil {
+			return fmt.Errorf("%v: resize rule %v: %w", source, ruleName(i, config.ResizeRules[i]), err)
+		}
+	}
+	for i := range config.Ignore {
+		if err := config.Ignore[i].compile(); err != nil {
+			return fmt.Errorf("%v: ignore #%d: %w", source, i+1, err)
+		}
+	}
+	return nil
+}
+
+// setFlags returns the names of the flags that were provided on the command line.
+func setFlags() map[string]bool {
+	result := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		result[f.Name] = true
+	})
+	return result
+}
+
+// findConfigFile searches for a project configuration file in the directory containing path (or the current directory,
+// if path is empty) and each of its parents.
+// It returns an empty string if no configuration file was found.
+func findConfigFile(path string) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Dir(abs)
+	}
+	for {
+		for _, name := range configFileNames {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfig reads and parses the project configuration file at path.
+func loadConfig(path string) (*projectConfig, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config projectConfig
+	if err := yaml.Unmarshal(contents, &config); err != nil {
+		return nil, fmt.Errorf("could not parse %v: %w", path, err)
+	}
+	if err := config.validate(path); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// applyConfig loads the project configuration (if any) for the file being processed, and uses it to fill in any
+// settings that weren't explicitly provided on the command line.
+func applyConfig() error {
+	path := *configFile
+	if path == "" {
+		var err error
+		path, err = findConfigFile(*file)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			// No configuration file; just use the flags.
+			return nil
+		}
+	}
+	config, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	set := setFlags()
+	if config.TableWidth != nil && !set["table_width"] {
+		*tableWidth = *config.TableWidth
+	}
+	if config.IgnoreErrors != nil && !set["ignore_errors"] {
+		*ignoreErrors = *config.IgnoreErrors
+	}
+	resizeRules = append(resizeRules, config.ResizeRules...)
+	ignoreRules = append(ignoreRules, config.Ignore...)
+	return nil
+}
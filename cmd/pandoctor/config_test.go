@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeFiles writes the files (relative to dir) for the test.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// parseFlags parses the command line args for the duration of the test, as if they were the only flags provided.
+func parseFlags(t *testing.T, args ...string) {
+	t.Helper()
+	old := flag.CommandLine
+	values := make(map[string]string)
+	fs := flag.NewFlagSet(old.Name(), flag.ContinueOnError)
+	old.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	flag.CommandLine = fs
+	t.Cleanup(func() {
+		flag.CommandLine = old
+		for name, value := range values {
+			old.Lookup(name).Value.Set(value)
+		}
+	})
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse(%q) = %v", args, err)
+	}
+}
+
+// saveRules restores the resize and ignore rules at the end of the test.
+func saveRules(t *testing.T) {
+	oldResizeRules, oldIgnoreRules := resizeRules, ignoreRules
+	t.Cleanup(func() { resizeRules, ignoreRules = oldResizeRules, oldIgnoreRules })
+}
+
+func TestFindConfigFile(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		files []string
+		path  string
+		want  string
+	}{
+		{
+			name:  "same directory",
+			files: []string{"docs/.pandoctor.yaml"},
+			path:  "docs/doc.md",
+			want:  "docs/.pandoctor.yaml",
+		},
+		{
+			name:  "parent directory",
+			files: []string{".pandoctor.json"},
+			path:  "docs/api/doc.md",
+			want:  ".pandoctor.json",
+		},
+		{
+			name:  "nearest directory",
+			files: []string{".pandoctor.yaml", "docs/.pandoctor.yml"},
+			path:  "docs/api/doc.md",
+			want:  "docs/.pandoctor.yml",
+		},
+		{
+			name:  "preferred name",
+			files: []string{"docs/.pandoctor.json", "docs/.pandoctor.yaml"},
+			path:  "docs/doc.md",
+			want:  "docs/.pandoctor.yaml",
+		},
+		{
+			name:  "below the file",
+			files: []string{"docs/api/.pandoctor.yaml", "other/.pandoctor.yaml"},
+			path:  "docs/doc.md",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{tc.path: ""}
+			for _, name := range tc.files {
+				files[name] = "table_width: 80\n"
+			}
+			writeFiles(t, dir, files)
+			got, err := findConfigFile(filepath.Join(dir, tc.path))
+			if err != nil {
+				t.Fatalf("findConfigFile() = %v", err)
+			}
+			want := ""
+			if tc.want != "" {
+				want = filepath.Join(dir, tc.want)
+			}
+			if got != want {
+				t.Errorf("findConfigFile() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".pandoctor.yaml": `table_width: 60
+ignore_errors: true
+resize_rules:
+  - name: config
+    columns: [Offset, Name]
+    widths: ['10', '*']
+`,
+		"docs/doc.md": "# Doc\n",
+	})
+	doc := filepath.Join(dir, "docs/doc.md")
+	for _, tc := range []struct {
+		name             string
+		args             []string
+		wantTableWidth   int
+		wantIgnoreErrors bool
+		wantRules        []string
+	}{
+		{
+			name:             "config only",
+			args:             []string{"--file", doc},
+			wantTableWidth:   60,
+			wantIgnoreErrors: true,
+			wantRules:        []string{"config"},
+		},
+		{
+			name:             "flags override the config",
+			args:             []string{"--file", doc, "--table_width", "100", "--ignore_errors=false"},
+			wantTableWidth:   100,
+			wantIgnoreErrors: false,
+			wantRules:        []string{"config"},
+		},
+		{
+			name:             "flags set to their defaults",
+			args:             []string{"--file", doc, "--table_width", "120"},
+			wantTableWidth:   120,
+			wantIgnoreErrors: true,
+			wantRules:        []string{"config"},
+		},
+		{
+			name:             "command line rule first",
+			args:             []string{"--file", doc, "--match_columns", "Offset,Name", "--new_widths", "20,*"},
+			wantTableWidth:   60,
+			wantIgnoreErrors: true,
+			wantRules:        []string{"command line", "config"},
+		},
+		{
+			name:             "explicit config",
+			args:             []string{"--file", filepath.Join(t.TempDir(), "doc.md"), "--config", filepath.Join(dir, ".pandoctor.yaml")},
+			wantTableWidth:   60,
+			wantIgnoreErrors: true,
+			wantRules:        []string{"config"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			saveRules(t)
+			resizeRules, ignoreRules = nil, nil
+			parseFlags(t, tc.args...)
+			if err := applyConfig(); err != nil {
+				t.Fatalf("applyConfig() = %v", err)
+			}
+			if err := validateResizeTablesArgs(); err != nil {
+				t.Fatalf("validateResizeTablesArgs() = %v", err)
+			}
+			if *tableWidth != tc.wantTableWidth {
+				t.Errorf("--table_width = %v, want %v", *tableWidth, tc.wantTableWidth)
+			}
+			if *ignoreErrors != tc.wantIgnoreErrors {
+				t.Errorf("--ignore_errors = %v, want %v", *ignoreErrors, tc.wantIgnoreErrors)
+			}
+			var rules []string
+			for _, rule := range resizeRules {
+				rules = append(rules, rule.Name)
+			}
+			if diff := cmp.Diff(tc.wantRules, rules); diff != "" {
+				t.Errorf("resize rules diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
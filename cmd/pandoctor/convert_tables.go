@@ -14,7 +14,7 @@ import (
 )
 
 var (
-	tableWidth = flag.Int("table_width", 120, "width of output tables")
+	tableWidth = flag.Int("table_width", 120, "width of output tables (tables whose columns are fitted to their contents are at most this wide)")
 )
 
 func validateConvertTablesArgs() error {
@@ -24,9 +24,45 @@ func validateConvertTablesArgs() error {
 	return nil
 }
 
+// htmlTableRe matches an HTML table. Each line may be indented or prefixed with block quote markers.
+var htmlTableRe = regexp.MustCompile("(?m)^[ \t>]*<table.*>\n([ \t>]*<.*\n)*[ \t>]*</table>")
+
+// findHTMLTables returns the locations of the HTML tables in the document.
+func findHTMLTables(contents []byte) []tableBlock {
+	return matchTables(contents, htmlTableRe)
+}
+
 func convertTables(contents []byte) ([]byte, error) {
-	tableRe := regexp.MustCompile("<table.*>\n(<.*\n)*</table>")
-	return tableRe.ReplaceAllFunc(contents, rewriteHTMLTableAsGrid), nil
+	return processTables(contents, "convert_tables", findHTMLTables(contents), convertHTMLTable), nil
+}
+
+// convertHTMLTable converts the HTML table in the given block into a grid table.
+func convertHTMLTable(contents []byte, block tableBlock) ([]byte, string, error) {
+	prefix := block.prefix(contents)
+	result, err := rewriteHTMLTableAsGrid([]byte(gridtable.StripPrefix(string(block.text(contents)), prefix)))
+	if err != nil {
+		return nil, "", err
+	}
+	opts, err := tableOptionsAt(contents, block)
+	if err != nil {
+		return nil, "", err
+	}
+	if opts.changesTable() {
+		// Apply the options to the converted table.
+		grid, err := gridtable.ReadBlock(string(result))
+		if err != nil {
+			return nil, "", err
+		}
+		if err := opts.apply(grid, *tableWidth); err != nil {
+			return nil, "", err
+		}
+		text, err := grid.Format(convertedCaptionWidth())
+		if err != nil {
+			return nil, "", err
+		}
+		result = []byte(text)
+	}
+	return []byte(gridtable.AddPrefix(string(result), prefix)), statusChanged, nil
 }
 
 func getTableNode(contents []byte) (*html.Node, error) {
@@ -59,39 +95,59 @@ func getTableNode(contents []byte) (*html.Node, error) {
 
 }
 
-func rewriteHTMLTableAsGrid(contents []byte) []byte {
+// An htmlCell is a td or th element of an HTML table.
+type htmlCell struct {
+	node *html.Node
+	// The values of the rowspan and colspan attributes, or 0 if they were not provided.
+	rowSpan, colSpan int
+}
+
+// An htmlTable is an HTML table, parsed into the parts pandoctor needs to convert it.
+type htmlTable struct {
+	caption gridtable.Caption
+	config  *gridtable.Config
+	// Whether the table had a colgroup, i.e. whether the column widths in config came from the table.
+	hasColgroup bool
+	// The rows of the (first) thead and the (first) tbody.
+	head, body [][]htmlCell
+}
+
+// parseHTMLTable parses an HTML table.
+func parseHTMLTable(contents []byte) (*htmlTable, error) {
 	table, err := getTableNode(contents)
-	caption := ""
-	id := ""
+	if err != nil {
+		return nil, fmt.Errorf("could not parse table: %w", err)
+	}
+	result := htmlTable{
+		caption: gridtable.Caption{
+			Prefix: "Table:",
+		},
+	}
 	for _, attr := range table.Attr {
 		if attr.Key == "id" {
-			id = attr.Val
+			result.caption.Attributes.ID = attr.Val
+		}
+		if attr.Key == "class" {
+			result.caption.Attributes.Classes = strings.Fields(attr.Val)
 		}
 	}
 	for child := range children(table) {
 		if child.Type == html.ElementNode && child.Data == "caption" {
-			caption = flatten(child)
-		}
-	}
-	config, err := generateTableConfig(table)
-	if err != nil {
-		if *ignoreErrors {
-			return contents
+			result.caption.Text = strings.Join(strings.Fields(flatten(child)), " ")
 		}
-		return []byte(fmt.Sprintf("Could not generate table config: %v", err))
 	}
-	w, err := gridtable.NewWriter(*config)
+	result.config, err = generateTableConfig(table)
 	if err != nil {
-		if *ignoreErrors {
-			return contents
-		}
-		return []byte(fmt.Sprintf("Could not initialize table writer: %v", err))
+		return nil, fmt.Errorf("could not generate table config: %w", err)
 	}
 	// find the (first) thead and (first) tbody
 	var thead *html.Node
 	var tbody *html.Node
 	for child := range children(table) {
 		if child.Type == html.ElementNode {
+			if child.Data == "colgroup" {
+				result.hasColgroup = true
+			}
 			if child.Data == "thead" {
 				thead = child
 			}
@@ -101,88 +157,89 @@ func rewriteHTMLTableAsGrid(contents []byte) []byte {
 		}
 	}
 	if tbody == nil {
-		if *ignoreErrors {
-			return contents
-		}
-		return []byte(fmt.Sprintf("Could not parse table: no <tbody> was found"))
+		return nil, fmt.Errorf("could not parse table: no <tbody> was found")
 	}
-	for tr := range children(thead) {
+	if result.head, err = htmlRows(thead); err != nil {
+		return nil, err
+	}
+	if result.body, err = htmlRows(tbody); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// htmlRows returns the cells of each tr element in the given thead or tbody.
+func htmlRows(section *html.Node) ([][]htmlCell, error) {
+	var result [][]htmlCell
+	for tr := range children(section) {
 		if tr.Type != html.ElementNode || tr.Data != "tr" {
 			continue
 		}
-		i := 0
+		var row []htmlCell
 		for td := range children(tr) {
 			if td.Type != html.ElementNode || (td.Data != "td" && td.Data != "th") {
 				continue
 			}
 			colspan, err := numericAttribute(td.Attr, "colspan")
 			if err != nil {
-				if *ignoreErrors {
-					return contents
-				}
-				return []byte(fmt.Sprintf("Could not parse colspan: %v", err))
+				return nil, fmt.Errorf("could not parse colspan: %w", err)
 			}
 			rowspan, err := numericAttribute(td.Attr, "rowspan")
 			if err != nil {
-				if *ignoreErrors {
-					return contents
-				}
-				return []byte(fmt.Sprintf("Could not parse rowspan: %v", err))
+				return nil, fmt.Errorf("could not parse rowspan: %w", err)
 			}
+			row = append(row, htmlCell{
+				node:    td,
+				rowSpan: rowspan,
+				colSpan: colspan,
+			})
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// rewriteHTMLTableAsGrid converts an HTML table into a grid table with a caption.
+func rewriteHTMLTableAsGrid(contents []byte) ([]byte, error) {
+	table, err := parseHTMLTable(contents)
+	if err != nil {
+		return nil, err
+	}
+	w, err := gridtable.NewWriter(*table.config)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize table writer: %w", err)
+	}
+	for _, row := range table.head {
+		i := 0
+		for _, td := range row {
 			cell := gridtable.Cell{
-				Text:    flatten(td),
-				RowSpan: rowspan,
-				ColSpan: colspan,
+				Text:    flatten(td.node),
+				RowSpan: td.rowSpan,
+				ColSpan: td.colSpan,
 			}
 			if err := w.WriteColumn(i, cell); err != nil {
-				if *ignoreErrors {
-					return contents
-				}
-				return []byte(fmt.Sprintf("Could not write cell: %v", err))
+				return nil, fmt.Errorf("could not write cell: %w", err)
 			}
-			i += colspan
+			i += td.colSpan
 			i++
 		}
 		w.NextRow()
 	}
-	for tr := range children(tbody) {
-		if tr.Type != html.ElementNode || tr.Data != "tr" {
-			continue
-		}
+	for _, row := range table.body {
 		i := 0
-		for td := range children(tr) {
-			if td.Type != html.ElementNode || (td.Data != "td" && td.Data != "th") {
-				continue
-			}
-			colspan, err := numericAttribute(td.Attr, "colspan")
-			if err != nil {
-				if *ignoreErrors {
-					return contents
-				}
-				return []byte(fmt.Sprintf("Could not parse colspan: %v", err))
-			}
-			rowspan, err := numericAttribute(td.Attr, "rowspan")
-			if err != nil {
-				if *ignoreErrors {
-					return contents
-				}
-				return []byte(fmt.Sprintf("Could not parse rowspan: %v", err))
-			}
-			if rowspan != 0 {
-				if *ignoreErrors {
-					return contents
-				}
-				return []byte("rowspan not currently supported")
+		for _, td := range row {
+			if td.rowSpan != 0 {
+				return nil, fmt.Errorf("rowspan not currently supported")
 			}
+			colspan := td.colSpan
 			if colspan != 0 {
 				// HTML uses colspan=3 to represent a row that spans 3 columns total.
 				colspan -= 1
 			}
 			cell := gridtable.Cell{
-				Text:    flatten(td),
+				Text:    flatten(td.node),
 				ColSpan: colspan,
 			}
-			cell.Text = flatten(td)
 			w.WriteColumn(i, cell)
 			i += colspan
 			i++
@@ -190,23 +247,22 @@ func rewriteHTMLTableAsGrid(contents []byte) []byte {
 		w.NextRow()
 	}
 	var sb strings.Builder
-	sb.WriteString("Table:")
-	if caption != "" {
-		fmt.Fprintf(&sb, " %v", caption)
-	}
-	if id != "" {
-		fmt.Fprintf(&sb, " {#%v}", id)
-	}
+	sb.WriteString(table.caption.Format(convertedCaptionWidth()))
 	sb.WriteString("\n\n")
 	result, err := w.String()
 	if err != nil {
-		if *ignoreErrors {
-			return contents
-		}
-		return []byte(fmt.Sprintf("Could not render grid table: %v", err))
+		return nil, fmt.Errorf("could not render grid table: %w", err)
 	}
 	sb.WriteString(result)
-	return []byte(sb.String())
+	return []byte(sb.String()), nil
+}
+
+// convertedCaptionWidth returns the width to wrap the captions of converted tables to, or 0 to leave them unwrapped.
+func convertedCaptionWidth() int {
+	if *wrapCaptions {
+		return *tableWidth
+	}
+	return 0
 }
 
 func flatten(node *html.Node) string {
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/chrisfenner/pandoctor/pkg/csvgrid"
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	headerRows = flag.Int("header_rows", 1, "number of rows of the CSV data that form the header of the table for csv_table")
+	delimiter  = flag.String("delimiter", "", "field delimiter of the CSV data for csv_table: a single character or \"tab\" (default: tab for .tsv files, comma otherwise)")
+)
+
+// A csvSource describes a CSV or TSV file to render as a grid table.
+type csvSource struct {
+	path          string
+	comma         rune
+	numHeaderRows int
+	// The widths of the columns, or nil to fit the columns to their contents within --table_width.
+	widths []gridtable.WidthSpec
+	// The caption and ID of the table, if any.
+	caption, id string
+}
+
+// parseDelimiter parses a field delimiter: a single character or "tab". If it's empty, the delimiter is chosen based
+// on the extension of path.
+func parseDelimiter(s string, path string) (rune, error) {
+	switch {
+	case s == "":
+		return csvgrid.CommaForPath(path), nil
+	case s == "tab" || s == `\t`:
+		return '\t', nil
+	case utf8.RuneCountInString(s) == 1:
+		r, _ := utf8.DecodeRuneInString(s)
+		return r, nil
+	default:
+		return 0, fmt.Errorf("invalid delimiter %q (must be a single character or \"tab\")", s)
+	}
+}
+
+// csvSourceFromFlags describes the CSV file given by --file for csv_table.
+func csvSourceFromFlags() (*csvSource, error) {
+	source := csvSource{
+		path:          *file,
+		numHeaderRows: *headerRows,
+	}
+	var err error
+	if source.comma, err = parseDelimiter(*delimiter, *file); err != nil {
+		return nil, fmt.Errorf("--delimiter: %w", err)
+	}
+	if *newWidths != "" {
+		if source.widths, err = gridtable.ParseWidthSpecs(*newWidths); err != nil {
+			return nil, fmt.Errorf("--new_widths: %w", err)
+		}
+	}
+	return &source, nil
+}
+
+// csvSourceFromAttributes describes the CSV file at path, given the other attributes of a table directive or marker,
+// e.g. {.table source=data.csv header=1 delimiter=; widths="20,*" caption="Some data" #tbl-data}.
+// A relative path is relative to dir.
+func csvSourceFromAttributes(path string, attrs *gridtable.Attributes, dir string) (*csvSource, error) {
+	source := csvSource{
+		path:          relativeTo(dir, path),
+		numHeaderRows: 1,
+		caption:       attrs.Get("caption"),
+		id:            attrs.ID,
+	}
+	var err error
+	if source.comma, err = parseDelimiter(attrs.Get("delimiter"), source.path); err != nil {
+		return nil, err
+	}
+	if header := attrs.Get("header"); header != "" {
+		if source.numHeaderRows, err = strconv.Atoi(header); err != nil {
+			return nil, fmt.Errorf("invalid header %q (must be a number of rows)", header)
+		}
+	}
+	if widths := attrs.Get("widths"); widths != "" {
+		if source.widths, err = gridtable.ParseWidthSpecs(widths); err != nil {
+			return nil, fmt.Errorf("widths: %w", err)
+		}
+	}
+	return &source, nil
+}
+
+// block reads the CSV file and lays it out as a grid table block.
+func (s *csvSource) block() (*gridtable.Block, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	table, err := csvgrid.Read(f, csvgrid.Options{Comma: s.comma, NumHeaderRows: s.numHeaderRows})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", s.path, err)
+	}
+	return layoutTable(table, s.widths, s.caption, s.id)
+}
+
+// layoutTable chooses the widths of the columns of a generated table and gives it a caption (before the table) if it
+// has a caption or an ID. If widths is nil, the columns are fitted to their contents within --table_width.
+func layoutTable(table *gridtable.Table, widths []gridtable.WidthSpec, caption, id string) (*gridtable.Block, error) {
+	config := table.Config()
+	if widths != nil {
+		var err error
+		if config.Columns, err = gridtable.ResolveWidths(widths, config.Columns, *tableWidth); err != nil {
+			return nil, err
+		}
+	} else {
+		table.FitColumns(*tableWidth)
+		config = table.Config()
+	}
+	block := gridtable.Block{
+		Config: config,
+		Rows:   table.Rows(),
+	}
+	if caption != "" || id != "" {
+		block.Caption = &gridtable.Caption{
+			Prefix: "Table:",
+			Text:   caption,
+			Attributes: gridtable.Attributes{
+				ID: id,
+			},
+		}
+		block.CaptionPosition = gridtable.CaptionBefore
+	}
+	return &block, nil
+}
+
+// format renders the CSV file as a grid table block, wrapping the caption if requested.
+func (s *csvSource) format() (string, error) {
+	block, err := s.block()
+	if err != nil {
+		return "", err
+	}
+	return formatGeneratedBlock(block)
+}
+
+// formatGeneratedBlock renders a generated table block, wrapping the caption if requested.
+func formatGeneratedBlock(block *gridtable.Block) (string, error) {
+	captionWidth := 0
+	if *wrapCaptions {
+		captionWidth = block.Width()
+	}
+	return block.Format(captionWidth)
+}
+
+// printCSVTable renders the CSV file given by --file as a grid table.
+func printCSVTable(w io.Writer) error {
+	source, err := csvSourceFromFlags()
+	if err != nil {
+		return err
+	}
+	text, err := source.format()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, text)
+	return err
+}
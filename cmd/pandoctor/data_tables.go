@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/chrisfenner/pandoctor/pkg/datagrid"
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+// A dataSource describes a YAML or JSON file to render as a grid table.
+type dataSource struct {
+	path string
+	// The dot-separated keys leading to the list of records in the file, or "" if the file is the list.
+	records string
+	// The template describing the columns, or nil to show every field of the records.
+	template *datagrid.Template
+	// The widths of the columns, or nil to use the template's widths (or fit the columns to their contents).
+	widths []gridtable.WidthSpec
+	// The caption and ID of the table, if any. They override the template's.
+	caption, id string
+}
+
+// dataSourceFromAttributes describes the data file at path, given the other attributes of a table marker, e.g.
+// source=regs.yaml records=registers template=regs.table.yaml widths="10,*" caption="Registers" #tbl-regs.
+// Relative paths are relative to dir.
+func dataSourceFromAttributes(path string, attrs *gridtable.Attributes, dir string) (*dataSource, error) {
+	source := dataSource{
+		path:    relativeTo(dir, path),
+		records: attrs.Get("records"),
+		caption: attrs.Get("caption"),
+		id:      attrs.ID,
+	}
+	if path := attrs.Get("template"); path != "" {
+		data, err := os.ReadFile(relativeTo(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		if source.template, err = datagrid.ParseTemplate(data); err != nil {
+			return nil, fmt.Errorf("%v: %w", path, err)
+		}
+	}
+	if widths := attrs.Get("widths"); widths != "" {
+		var err error
+		if source.widths, err = gridtable.ParseWidthSpecs(widths); err != nil {
+			return nil, fmt.Errorf("widths: %w", err)
+		}
+	}
+	return &source, nil
+}
+
+// relativeTo resolves a relative path against dir.
+func relativeTo(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// block reads the data file and lays it out as a grid table block.
+func (s *dataSource) block() (*gridtable.Block, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	records, err := datagrid.Records(data, s.records)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", s.path, err)
+	}
+	tmpl := s.template
+	if tmpl == nil {
+		tmpl = datagrid.DefaultTemplate(records)
+	}
+	table, err := datagrid.Table(records, tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", s.path, err)
+	}
+	widths, caption, id := s.widths, s.caption, s.id
+	if widths == nil {
+		widths = tmpl.Widths()
+	}
+	if caption == "" {
+		caption = tmpl.Caption
+	}
+	if id == "" {
+		id = tmpl.ID
+	}
+	return layoutTable(table, widths, caption, id)
+}
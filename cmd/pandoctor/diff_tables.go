@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/chrisfenner/pandoctor/pkg/tablediff"
+)
+
+// A docTable is a grid table read from a version of a document for diff_tables.
+type docTable struct {
+	// The position of the table in the document (counting from 1) and the line it starts on.
+	n, line     int
+	id, caption string
+	table       *gridtable.Table
+}
+
+// label identifies the table in the output of diff_tables: by its ID, its caption or its position.
+func (t *docTable) label() string {
+	switch {
+	case t.id != "":
+		return "#" + t.id
+	case t.caption != "":
+		return strconv.Quote(t.caption)
+	default:
+		return strconv.Itoa(t.n)
+	}
+}
+
+// readDocTables reads the grid tables in a version of a document. Tables that can't be read are reported and
+// skipped; readDocTables returns how many there were.
+func readDocTables(path string, contents []byte) ([]*docTable, int) {
+	var result []*docTable
+	failed := 0
+	for n, loc := range findGridTables(contents) {
+		t, err := readDocTable(loc.text(contents))
+		if err != nil {
+			failed++
+			if !*ignoreErrors {
+				reportTableErrorIn(path, contents, loc.tableStart, tableErrorInFile(contents, loc.tableStart, err))
+			}
+			continue
+		}
+		t.n, t.line = n+1, lineNumber(contents, loc.tableStart)
+		result = append(result, t)
+	}
+	return result, failed
+}
+
+func readDocTable(text []byte) (*docTable, error) {
+	block, err := gridtable.ReadBlock(string(text))
+	if err != nil {
+		return nil, err
+	}
+	table, err := gridtable.NewTable(block.Config, block.Rows)
+	if err != nil {
+		return nil, err
+	}
+	result := docTable{table: table}
+	if block.Caption != nil {
+		result.id = block.Caption.Attributes.ID
+		result.caption = strings.Join(strings.Fields(block.Caption.Text), " ")
+	}
+	return &result, nil
+}
+
+// pairDocTables pairs the tables in the old and new versions of a document: first by ID, then by caption, then the
+// rest in order. The tables that aren't paired were removed or added.
+func pairDocTables(before, after []*docTable) (pairs [][2]*docTable, removed, added []*docTable) {
+	paired := make(map[*docTable]bool)
+	pairBy := func(key func(*docTable) string) {
+		for _, a := range after {
+			if paired[a] || key(a) == "" {
+				continue
+			}
+			for _, b := range before {
+				if !paired[b] && key(b) == key(a) {
+					pairs = append(pairs, [2]*docTable{b, a})
+					paired[a], paired[b] = true, true
+					break
+				}
+			}
+		}
+	}
+	pairBy(func(t *docTable) string { return t.id })
+	pairBy(func(t *docTable) string {
+		if t.id != "" {
+			// Tables with different IDs are different tables, even if they have the same caption.
+			return ""
+		}
+		return t.caption
+	})
+	pairBy(func(t *docTable) string { return "*" })
+	for _, b := range before {
+		if !paired[b] {
+			removed = append(removed, b)
+		}
+	}
+	for _, a := range after {
+		if !paired[a] {
+			added = append(added, a)
+		}
+	}
+	return pairs, removed, added
+}
+
+// diffTables prints the differences between the grid tables in an old version of a document and the new version
+// given by --file.
+func diffTables(w io.Writer, oldPath string) error {
+	oldContents, err := os.ReadFile(oldPath)
+	if err != nil {
+		return err
+	}
+	newContents, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	return diffDocuments(w, oldPath, oldContents, *file, newContents)
+}
+
+// diffDocuments prints the differences between the grid tables in two versions of a document, one line per change.
+// Removed tables are reported at their position in the old version; everything else at its position in the new
+// version.
+func diffDocuments(w io.Writer, oldPath string, oldContents []byte, newPath string, newContents []byte) error {
+	before, oldFailed := readDocTables(oldPath, oldContents)
+	after, newFailed := readDocTables(newPath, newContents)
+	pairs, removed, added := pairDocTables(before, after)
+	for _, t := range removed {
+		if _, err := fmt.Fprintf(w, "%v:%d: table %v removed\n", oldPath, t.line, t.label()); err != nil {
+			return err
+		}
+	}
+	for _, t := range added {
+		if _, err := fmt.Fprintf(w, "%v:%d: table %v added\n", newPath, t.line, t.label()); err != nil {
+			return err
+		}
+	}
+	for _, pair := range pairs {
+		var headings []string
+		if pair[1].table.Config().NumHeaderRows != 0 {
+			headings = tablediff.Text(pair[1].table)[0]
+		}
+		for _, change := range tablediff.Diff(pair[0].table, pair[1].table) {
+			if _, err := fmt.Fprintf(w, "%v:%d: table %v: %v\n", newPath, pair[1].line, pair[1].label(), describeChange(change, headings)); err != nil {
+				return err
+			}
+		}
+	}
+	if failed := oldFailed + newFailed; failed != 0 && !*ignoreErrors {
+		return fmt.Errorf("could not compare %d tables", failed)
+	}
+	return nil
+}
+
+// describeChange describes a change to a table with the given headings (if any). Rows and columns are counted from 1,
+// including the header rows.
+func describeChange(change tablediff.Change, headings []string) string {
+	switch change.Kind {
+	case tablediff.ColumnAdded:
+		return fmt.Sprintf("column %d %q added", change.NewColumn+1, change.New[0])
+	case tablediff.ColumnRemoved:
+		return fmt.Sprintf("old column %d %q removed", change.OldColumn+1, change.Old[0])
+	case tablediff.RowAdded:
+		return fmt.Sprintf("row %d added: %v", change.NewRow+1, quoteCells(change.New))
+	case tablediff.RowRemoved:
+		return fmt.Sprintf("old row %d removed: %v", change.OldRow+1, quoteCells(change.Old))
+	default:
+		column := strconv.Itoa(change.NewColumn + 1)
+		if change.NewRow != 0 && headings != nil && headings[change.NewColumn] != "" {
+			column += fmt.Sprintf(" %q", headings[change.NewColumn])
+		}
+		return fmt.Sprintf("row %d column %v changed: %q -> %q", change.NewRow+1, column, change.Old[0], change.New[0])
+	}
+}
+
+// quoteCells formats the text of a row's cells, e.g. `"CTRL" | "0x00"`.
+func quoteCells(cells []string) string {
+	var quoted []string
+	for _, cell := range cells {
+		quoted = append(quoted, strconv.Quote(cell))
+	}
+	return strings.Join(quoted, " | ")
+}
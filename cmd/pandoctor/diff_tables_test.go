@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPairDocTables(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		before, after []*docTable
+		// The pairs, removed and added tables, by their positions.
+		wantPairs              []string
+		wantRemoved, wantAdded []int
+	}{
+		{
+			name:      "by ID",
+			before:    []*docTable{{n: 1, id: "tbl-a", caption: "A"}, {n: 2, id: "tbl-b"}},
+			after:     []*docTable{{n: 1, id: "tbl-b"}, {n: 2, id: "tbl-a", caption: "Renamed"}},
+			wantPairs: []string{"2-1", "1-2"},
+		},
+		{
+			name:      "by caption",
+			before:    []*docTable{{n: 1, caption: "One"}, {n: 2, caption: "Two"}},
+			after:     []*docTable{{n: 1, caption: "Two"}, {n: 2, caption: "One"}},
+			wantPairs: []string{"2-1", "1-2"},
+		},
+		{
+			name:      "ID before caption",
+			before:    []*docTable{{n: 1, id: "tbl-a", caption: "Same"}, {n: 2, caption: "Same"}},
+			after:     []*docTable{{n: 1, caption: "Same"}, {n: 2, id: "tbl-a", caption: "Same"}},
+			wantPairs: []string{"1-2", "2-1"},
+		},
+		{
+			name:        "by position",
+			before:      []*docTable{{n: 1}, {n: 2}, {n: 3}},
+			after:       []*docTable{{n: 1}, {n: 2}},
+			wantPairs:   []string{"1-1", "2-2"},
+			wantRemoved: []int{3},
+		},
+		{
+			name:      "position after ID and caption",
+			before:    []*docTable{{n: 1, id: "tbl-x"}, {n: 2, caption: "Cap"}, {n: 3}},
+			after:     []*docTable{{n: 1}, {n: 2, caption: "Cap"}, {n: 3, id: "tbl-x"}, {n: 4}},
+			wantPairs: []string{"1-3", "2-2", "3-1"},
+			wantAdded: []int{4},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pairs, removed, added := pairDocTables(tc.before, tc.after)
+			var gotPairs []string
+			for _, pair := range pairs {
+				gotPairs = append(gotPairs, fmt.Sprintf("%d-%d", pair[0].n, pair[1].n))
+			}
+			positions := func(tables []*docTable) []int {
+				var result []int
+				for _, table := range tables {
+					result = append(result, table.n)
+				}
+				return result
+			}
+			if diff := cmp.Diff(tc.wantPairs, gotPairs); diff != "" {
+				t.Errorf("pairDocTables() pairs diff (-want +got)\n%v", diff)
+			}
+			if diff := cmp.Diff(tc.wantRemoved, positions(removed)); diff != "" {
+				t.Errorf("pairDocTables() removed diff (-want +got)\n%v", diff)
+			}
+			if diff := cmp.Diff(tc.wantAdded, positions(added)); diff != "" {
+				t.Errorf("pairDocTables() added diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
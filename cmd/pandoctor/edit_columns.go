@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	column      = flag.String("column", "", "column to edit, by heading (case-insensitive, MD formatting stripped; prefix with re: or glob: to match a pattern against the heading as written) or by position (e.g. #2)")
+	heading     = flag.String("heading", "", "new heading for rename_column and insert_column")
+	defaultText = flag.String("default", "", "content of the body cells of the column added by insert_column")
+	before      = flag.Bool("before", false, "set to make insert_column insert the new column before --column instead of after it")
+	columnWidth = flag.Int("column_width", 0, "width of the column added by insert_column (default: wide enough for --heading and --default)")
+	columnOrder = flag.String("column_order", "", "new order of the columns for reorder_columns (comma-separated, each selecting a column like --column)")
+)
+
+// A columnSelector selects a column of a table by its heading or by its position.
+type columnSelector struct {
+	// The selector as written, for error messages.
+	text string
+	// The (0-based) position of the column, or -1 to select by heading.
+	position int
+	heading  *textPattern
+}
+
+func parseColumnSelector(text string) (*columnSelector, error) {
+	if n, ok := strings.CutPrefix(text, "#"); ok {
+		if pos, err := strconv.Atoi(n); err == nil {
+			if pos < 1 {
+				return nil, fmt.Errorf("invalid column position %q (columns are numbered from 1)", text)
+			}
+			return &columnSelector{text: text, position: pos - 1}, nil
+		}
+	}
+	p, err := compileTextPattern(text)
+	if err != nil {
+		return nil, err
+	}
+	return &columnSelector{text: text, position: -1, heading: p}, nil
+}
+
+// find returns the index of the selected column in the table. Headings are taken from the first row, and a heading
+// that spans several columns can't be selected by name. A position covered by such a heading can't be selected either.
+func (s *columnSelector) find(table *gridtable.Table) (int, error) {
+	if s.position >= 0 {
+		if s.position >= table.NumColumns() {
+			return 0, fmt.Errorf("table has no column %v (it has %d columns)", s.text, table.NumColumns())
+		}
+		if table.Cell(0, s.position) == nil {
+			return 0, fmt.Errorf("column %v is covered by a heading that spans several columns", s.text)
+		}
+		return s.position, nil
+	}
+	result := -1
+	for j := range table.NumColumns() {
+		cell := table.Cell(0, j)
+		if cell == nil || cell.ColSpan != 0 || !s.heading.match(cell.Text) {
+			continue
+		}
+		if result != -1 {
+			return 0, fmt.Errorf("more than one column matches %q", s.text)
+		}
+		result = j
+	}
+	if result == -1 {
+		return 0, fmt.Errorf("no column matches %q", s.text)
+	}
+	return result, nil
+}
+
+// A columnEdit is the edit that a column editing action makes to the matched tables.
+type columnEdit struct {
+	matcher *tableMatcher
+	apply   func(table *gridtable.Table) error
+}
+
+// columnEditFromFlags validates the flags for the given column editing action and returns the edit they describe.
+func columnEditFromFlags(action string) (*columnEdit, error) {
+	matcher, err := tableMatcherFromFlags()
+	if err != nil {
+		return nil, err
+	}
+	if matcher == nil {
+		return nil, fmt.Errorf("%v requires --match_columns (or another --match_* flag) to select the tables to edit", action)
+	}
+	var selector *columnSelector
+	if *column != "" {
+		if selector, err = parseColumnSelector(*column); err != nil {
+			return nil, fmt.Errorf("--column: %w", err)
+		}
+	}
+	if *column == "" && action != "insert_column" && action != "reorder_columns" {
+		return nil, fmt.Errorf("%v requires --column", action)
+	}
+	result := columnEdit{matcher: matcher}
+	switch action {
+	case "drop_column":
+		result.apply = func(table *gridtable.Table) error {
+			j, err := selector.find(table)
+			if err != nil {
+				return err
+			}
+			width := table.Config().Columns[j].Width
+			if err := table.DeleteColumn(j); err != nil {
+				return err
+			}
+			// Keep the table as wide as it was.
+			return growColumns(table, width+1)
+		}
+	case "rename_column":
+		if *heading == "" {
+			return nil, fmt.Errorf("rename_column requires --heading")
+		}
+		result.apply = func(table *gridtable.Table) error {
+			j, err := selector.find(table)
+			if err != nil {
+				return err
+			}
+			table.Cell(0, j).Text = *heading
+			return nil
+		}
+	case "insert_column":
+		if *columnWidth < 0 {
+			return nil, fmt.Errorf("--column_width cannot be negative")
+		}
+		width := *columnWidth
+		if width == 0 {
+			width = max(len([]rune(*heading))+2, len([]rune(*defaultText))+2, 3)
+		}
+		result.apply = func(table *gridtable.Table) error {
+			return insertColumn(table, selector, width)
+		}
+	case "reorder_columns":
+		if *columnOrder == "" {
+			return nil, fmt.Errorf("reorder_columns requires --column_order")
+		}
+		var order []*columnSelector
+		for _, text := range strings.Split(*columnOrder, ",") {
+			s, err := parseColumnSelector(text)
+			if err != nil {
+				return nil, fmt.Errorf("--column_order: %w", err)
+			}
+			order = append(order, s)
+		}
+		result.apply = func(table *gridtable.Table) error {
+			return reorderColumns(table, order)
+		}
+	}
+	return &result, nil
+}
+
+// growColumns shares extra characters between the columns of the table, in proportion to their widths.
+func growColumns(table *gridtable.Table, extra int) error {
+	cols := table.Config().Columns
+	total := 0
+	for _, col := range cols {
+		total += col.Width
+	}
+	given := 0
+	for j, col := range cols {
+		share := extra * col.Width / total
+		if j == len(cols)-1 {
+			// Give what's left over by rounding to the last column.
+			share = extra - given
+		}
+		if err := table.SetColumnWidth(j, col.Width+share); err != nil {
+			return err
+		}
+		given += share
+	}
+	return nil
+}
+
+// insertColumn adds a column after the selected column (or before it, with --before), or at the end of the table if
+// there is no selected column. The first row gets --heading if the table has a header, and the body gets --default.
+func insertColumn(table *gridtable.Table, selector *columnSelector, width int) error {
+	pos := table.NumColumns()
+	if selector != nil {
+		j, err := selector.find(table)
+		if err != nil {
+			return err
+		}
+		pos = j + 1
+		if *before {
+			pos = j
+		}
+	}
+	if err := table.InsertColumn(pos, gridtable.ColumnSpec{Width: width}); err != nil {
+		return err
+	}
+	numHeaderRows := table.Config().NumHeaderRows
+	for i := range table.NumRows() {
+		// Cells that span across the new column cover it.
+		cell := table.Cell(i, pos)
+		if cell == nil {
+			continue
+		}
+		switch {
+		case i == 0 && numHeaderRows != 0:
+			cell.Text = *heading
+		case i >= numHeaderRows:
+			cell.Text = *defaultText
+		}
+	}
+	return nil
+}
+
+// reorderColumns moves the columns of the table into the given order, which must select every column exactly once.
+func reorderColumns(table *gridtable.Table, order []*columnSelector) error {
+	if len(order) != table.NumColumns() {
+		return fmt.Errorf("--column_order has %d columns, but the table has %d", len(order), table.NumColumns())
+	}
+	// current[k] is the original index of the column now at position k.
+	var current []int
+	seen := make(map[int]bool)
+	var want []int
+	for j := range table.NumColumns() {
+		current = append(current, j)
+	}
+	for _, s := range order {
+		j, err := s.find(table)
+		if err != nil {
+			return err
+		}
+		if seen[j] {
+			return fmt.Errorf("--column_order selects column %d more than once", j+1)
+		}
+		seen[j] = true
+		want = append(want, j)
+	}
+	for k, j := range want {
+		from := k
+		for current[from] != j {
+			from++
+		}
+		if err := table.MoveColumn(from, k); err != nil {
+			return err
+		}
+		current = slices.Insert(slices.Delete(current, from, from+1), k, j)
+	}
+	return nil
+}
+
+// editColumns applies the column editing action to the matched tables.
+func editColumns(contents []byte, action string) ([]byte, error) {
+	edit, err := columnEditFromFlags(action)
+	if err != nil {
+		return nil, err
+	}
+	return processTables(contents, action, findGridTables(contents), edit.editGridTable), nil
+}
+
+func (edit *columnEdit) editGridTable(contents []byte, loc tableBlock) ([]byte, string, error) {
+	block, err := gridtable.ReadBlock(string(loc.text(contents)))
+	if err != nil {
+		return nil, "", err
+	}
+	// We're not updating this table.
+	if !edit.matcher.match(block) {
+		return loc.text(contents), statusSkipped, nil
+	}
+	table, err := gridtable.NewTable(block.Config, block.Rows)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := edit.apply(table); err != nil {
+		return nil, "", err
+	}
+	block.Config = table.Config()
+	block.Rows = table.Rows()
+	result, status, err := renderBlock(contents, loc, block)
+	if errors.Is(err, gridtable.ErrBadWrap) {
+		return nil, "", fmt.Errorf("content no longer fits: %w", err)
+	}
+	return result, status, err
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// setFlag sets a flag for the duration of the test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	old := flag.Lookup(name).Value.String()
+	if err := flag.Set(name, value); err != nil {
+		t.Fatalf("flag.Set(%q, %q) = %v", name, value, err)
+	}
+	t.Cleanup(func() { flag.Set(name, old) })
+}
+
+// spannedHeaderTable has a heading that spans the first two columns.
+const spannedHeaderTable = `+-------+-------+-------+
+| Pair          | C     |
++=======+=======+=======+
+| 1     | 2     | 3     |
++-------+-------+-------+
+`
+
+func TestRenameColumnSpannedHeader(t *testing.T) {
+	for _, tc := range []struct {
+		column string
+		want   string
+	}{
+		{
+			column: "#1",
+			want: `+-------+-------+-------+
+| New           | C     |
++=======+=======+=======+
+| 1     | 2     | 3     |
++-------+-------+-------+
+`,
+		},
+		{
+			column: "#3",
+			want: `+-------+-------+-------+
+| Pair          | New   |
++=======+=======+=======+
+| 1     | 2     | 3     |
++-------+-------+-------+
+`,
+		},
+		{
+			column: "#2",
+			want:   "<!-- pandoctor: could not rename column: column #2 is covered by a heading that spans several columns -->\n\n" + spannedHeaderTable,
+		},
+		{
+			column: "Pair",
+			want:   "<!-- pandoctor: could not rename column: no column matches \"Pair\" -->\n\n" + spannedHeaderTable,
+		},
+	} {
+		t.Run(tc.column, func(t *testing.T) {
+			setFlag(t, "match_column_count", "3")
+			setFlag(t, "column", tc.column)
+			setFlag(t, "heading", "New")
+			got, err := editColumns([]byte(spannedHeaderTable), "rename_column")
+			if err != nil {
+				t.Fatalf("editColumns() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("editColumns() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestDropColumn(t *testing.T) {
+	setFlag(t, "match_columns", "Name,Type,Notes")
+	setFlag(t, "column", "Type")
+	got, err := editColumns([]byte(`+------+----------+-------+
+| Name | Type     | Notes |
++======+==========+=======+
+| a    | int      | x     |
++------+----------+-------+
+`), "drop_column")
+	if err != nil {
+		t.Fatalf("editColumns() = %v", err)
+	}
+	// The other columns share the dropped column's width.
+	want := `+-----------+-------------+
+| Name      | Notes       |
++===========+=============+
+| a         | x           |
++-----------+-------------+
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("editColumns() diff (-want +got)\n%v", diff)
+	}
+}
+
+func TestDropColumnSpannedHeader(t *testing.T) {
+	setFlag(t, "match_column_count", "3")
+	setFlag(t, "column", "#2")
+	got, err := editColumns([]byte(spannedHeaderTable), "drop_column")
+	if err != nil {
+		t.Fatalf("editColumns() = %v", err)
+	}
+	if !strings.HasPrefix(string(got), "<!-- pandoctor: could not drop column: column #2 is covered") {
+		t.Errorf("editColumns() = %v, want an annotation", string(got))
+	}
+}
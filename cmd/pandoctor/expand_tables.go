@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/chrisfenner/pandoctor/pkg/pandoc"
+	"github.com/chrisfenner/pandoctor/pkg/pandocgrid"
+)
+
+// fenceRe matches the opening line of a fenced code block with attributes, e.g. "```{.table source=data.csv}".
+var fenceRe = regexp.MustCompile("^(`{3,}|~{3,})[ \t]*(\\{.*\\})[ \t]*$")
+
+// tableMarkerRe matches the comment that starts a generated table, e.g. "<!-- pandoctor: table source=data.csv -->" or
+// "<!-- pandoctor:table source=regs.yaml template=regs.table.yaml -->". The spacing is lenient, so that a near miss is
+// reported as an error rather than ignored.
+var tableMarkerRe = regexp.MustCompile(`^<!--[ \t]*pandoctor:[ \t]*table\b[ \t]*(.*?)[ \t]*-->[ \t]*$`)
+
+// tableEndMarkerRe matches the comment that ends a generated table.
+var tableEndMarkerRe = regexp.MustCompile(`^<!--[ \t]*pandoctor:[ \t]*end[ \t]*-->[ \t]*$`)
+
+const tableEndMarker = "<!-- pandoctor: end -->"
+
+// A tableDirective is a request in the document for a generated table: either a fenced code block with the "table"
+// class, or a pair of marker comments around the table generated from it by a previous run (or written by hand).
+type tableDirective struct {
+	// The directive, and the table generated from it (if any), is contents[start:end].
+	start, end int
+	attrs      *gridtable.Attributes
+	// Whether the directive is a fenced code block.
+	fence bool
+	// Why the directive is invalid, if it is.
+	err error
+}
+
+// A tableSource is a file that a table is generated from.
+type tableSource interface {
+	// block reads the file and lays it out as a grid table block.
+	block() (*gridtable.Block, error)
+}
+
+// tableSourceFromAttributes describes the file given by the attributes of a table directive or marker. source=...
+// names a CSV or TSV file or a YAML or JSON file, told apart by its extension, while csv=... and data=... name a file
+// of that kind whatever its extension. Relative paths are relative to dir.
+func tableSourceFromAttributes(attrs *gridtable.Attributes, dir string) (tableSource, error) {
+	var keys []string
+	for _, key := range []string{"source", "csv", "data"} {
+		if attrs.Get(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	switch len(keys) {
+	case 0:
+		return nil, fmt.Errorf("a table needs a source= file (CSV, TSV, YAML or JSON)")
+	case 1:
+	default:
+		return nil, fmt.Errorf("a table can only have one of source=, csv= and data=")
+	}
+	key, path := keys[0], attrs.Get(keys[0])
+	if key == "source" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv", ".tsv":
+			key = "csv"
+		case ".yaml", ".yml", ".json":
+			key = "data"
+		default:
+			return nil, fmt.Errorf("can't tell what kind of file %v is from its extension (use csv= for a CSV or TSV file, or data= for a YAML or JSON file)", path)
+		}
+	}
+	if key == "csv" {
+		source, err := csvSourceFromAttributes(path, attrs, dir)
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	}
+	source, err := dataSourceFromAttributes(path, attrs, dir)
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// parseTableMarker parses the attributes in a table marker (without the curly braces of a fenced code block).
+func parseTableMarker(attrs string) (*gridtable.Attributes, error) {
+	return gridtable.ParseAttributes("{" + attrs + "}")
+}
+
+// tableMarker returns the comment that starts a table generated from a directive with the given attributes, so that
+// later runs can refresh the table.
+func tableMarker(attrs *gridtable.Attributes) string {
+	marker := *attrs
+	marker.Classes = slices.DeleteFunc(slices.Clone(attrs.Classes), func(class string) bool { return class == "table" })
+	text := strings.TrimSuffix(strings.TrimPrefix(marker.String(), "{"), "}")
+	return fmt.Sprintf("<!-- pandoctor: table %v -->\n", text)
+}
+
+// isTableDirective returns whether the attributes of a fenced code block make it a table directive.
+func isTableDirective(classes []string) bool {
+	return slices.Contains(classes, "table")
+}
+
+// findTableDirectives returns the table directives in the document, in order.
+func findTableDirectives(contents []byte) []tableDirective {
+	var result []tableDirective
+	for offset := frontMatterEnd(contents); offset < len(contents); {
+		line, next := nextLine(contents, offset)
+		if m := fenceRe.FindSubmatch(line); m != nil {
+			end := closingFence(contents, next, m[1])
+			if attrs, err := gridtable.ParseAttributes(string(m[2])); err == nil && isTableDirective(attrs.Classes) {
+				result = append(result, tableDirective{start: offset, end: end, attrs: attrs, fence: true})
+			}
+			// Skip the contents of any code block.
+			offset = end
+			continue
+		}
+		if m := tableMarkerRe.FindSubmatch(line); m != nil {
+			directive := markerDirective(contents, offset, next, string(m[1]))
+			result = append(result, directive)
+			offset = directive.end
+			continue
+		}
+		offset = next
+	}
+	return result
+}
+
+// markerDirective parses the table marker in contents[start:next] with the given attributes, and finds its end marker.
+func markerDirective(contents []byte, start, next int, attrs string) tableDirective {
+	directive := tableDirective{start: start, end: next}
+	directive.attrs, directive.err = parseTableMarker(attrs)
+	for offset := next; offset < len(contents); {
+		line, next := nextLine(contents, offset)
+		if tableEndMarkerRe.Match(line) {
+			directive.end = next
+			return directive
+		}
+		if tableMarkerRe.Match(line) {
+			break
+		}
+		offset = next
+	}
+	if directive.err == nil {
+		directive.err = fmt.Errorf("table marker has no matching end marker")
+	}
+	return directive
+}
+
+// nextLine returns the line starting at contents[offset] (without its newline) and the offset of the next line.
+func nextLine(contents []byte, offset int) ([]byte, int) {
+	end := bytes.IndexByte(contents[offset:], '\n')
+	if end == -1 {
+		return contents[offset:], len(contents)
+	}
+	return contents[offset : offset+end], offset + end + 1
+}
+
+// closingFence returns the offset just after the line that closes a code block opened with fence, searching from
+// contents[offset]. An unclosed code block runs to the end of the document.
+func closingFence(contents []byte, offset int, fence []byte) int {
+	for offset < len(contents) {
+		line, next := nextLine(contents, offset)
+		trimmed := bytes.Trim(line, " \t")
+		if len(trimmed) >= len(fence) && len(bytes.Trim(trimmed, string(fence[:1]))) == 0 {
+			return next
+		}
+		offset = next
+	}
+	return len(contents)
+}
+
+// expandTables replaces each table directive in the document with a pair of marker comments around the table
+// generated from its file, or refreshes the table between a pair of marker comments.
+func expandTables(contents []byte) ([]byte, error) {
+	directives := findTableDirectives(contents)
+	var blocks []tableBlock
+	byStart := make(map[int]*tableDirective)
+	for i, d := range directives {
+		blocks = append(blocks, tableBlock{start: d.start, end: d.end, tableStart: d.start, tableEnd: d.end})
+		byStart[d.start] = &directives[i]
+	}
+	dir := filepath.Dir(*file)
+	return processTables(contents, "expand_tables", blocks, func(contents []byte, loc tableBlock) ([]byte, string, error) {
+		return expandTableDirective(contents, loc, byStart[loc.start], dir)
+	}), nil
+}
+
+func expandTableDirective(contents []byte, loc tableBlock, d *tableDirective, dir string) ([]byte, string, error) {
+	if d.err != nil {
+		return nil, "", d.err
+	}
+	source, err := tableSourceFromAttributes(d.attrs, dir)
+	if err != nil {
+		return nil, "", err
+	}
+	block, err := source.block()
+	if err != nil {
+		return nil, "", err
+	}
+	table, err := formatGeneratedBlock(block)
+	if err != nil {
+		return nil, "", err
+	}
+	// Keep a marker as it was written.
+	marker := tableMarker(d.attrs)
+	if !d.fence {
+		line, _ := nextLine(contents, loc.start)
+		marker = string(line) + "\n"
+	}
+	result := []byte(marker + "\n" + table + "\n" + tableEndMarker + "\n")
+	if bytes.Equal(result, loc.text(contents)) {
+		return result, statusUnchanged, nil
+	}
+	return result, statusChanged, nil
+}
+
+// expandTableCodeBlock replaces the given block with the table generated from its file, if it is a table directive.
+// Relative paths are relative to the current directory.
+func expandTableCodeBlock(elem *pandoc.Element) error {
+	attr, _, ok := elem.CodeBlockText()
+	if !ok || !isTableDirective(attr.Classes) {
+		return nil
+	}
+	attrs := gridtable.Attributes{ID: attr.ID, Classes: attr.Classes, KeyVals: attr.KeyVals}
+	source, err := tableSourceFromAttributes(&attrs, ".")
+	if err != nil {
+		return err
+	}
+	block, err := source.block()
+	if err != nil {
+		return err
+	}
+	*elem = *pandocgrid.ToTable(block, *tableWidth).Element()
+	return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandTables(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"data.csv":  "Name,Value\nA,1\n",
+		"data.tsv":  "Name\tValue\nA\t1\n",
+		"data.txt":  "Name,Value\nA,1\n",
+		"data.yaml": "- name: A\n  value: 1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setFlag(t, "file", filepath.Join(dir, "doc.md"))
+	csvTable := `+------+-------+
+| Name | Value |
++======+=======+
+| A    | 1     |
++------+-------+
+`
+	dataTable := `+------+-------+
+| name | value |
++======+=======+
+| A    | 1     |
++------+-------+
+`
+	for _, tc := range []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{
+			name:     "csv fence",
+			contents: "```{.table csv=data.csv caption=Data}\n```\n",
+			want:     "<!-- pandoctor: table csv=data.csv caption=Data -->\n\nTable: Data\n\n" + csvTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "csv source fence",
+			contents: "```{.table source=data.csv}\n```\n",
+			want:     "<!-- pandoctor: table source=data.csv -->\n\n" + csvTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "tsv source marker",
+			contents: "<!-- pandoctor:table source=data.tsv -->\n<!-- pandoctor:end -->\n",
+			want:     "<!-- pandoctor:table source=data.tsv -->\n\n" + csvTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "csv with another extension",
+			contents: "<!-- pandoctor: table csv=data.txt -->\n<!-- pandoctor: end -->\n",
+			want:     "<!-- pandoctor: table csv=data.txt -->\n\n" + csvTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "data fence",
+			contents: "```{.table data=data.yaml}\n```\n",
+			want:     "<!-- pandoctor: table data=data.yaml -->\n\n" + dataTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "csv marker",
+			contents: "<!-- pandoctor: table csv=data.csv -->\n<!-- pandoctor: end -->\n",
+			want:     "<!-- pandoctor: table csv=data.csv -->\n\n" + csvTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "data marker",
+			contents: "<!-- pandoctor: table data=data.yaml -->\n\n| stale |\n\n<!-- pandoctor: end -->\n",
+			want:     "<!-- pandoctor: table data=data.yaml -->\n\n" + dataTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "marker spacing",
+			contents: "<!--pandoctor:table data=data.yaml-->\n<!--pandoctor:end-->\n",
+			want:     "<!--pandoctor:table data=data.yaml-->\n\n" + dataTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "no source",
+			contents: "<!-- pandoctor: table caption=Data -->\n<!-- pandoctor: end -->\n",
+			want:     "<!-- pandoctor: could not expand: a table needs a source= file (CSV, TSV, YAML or JSON) -->\n\n<!-- pandoctor: table caption=Data -->\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "unknown extension",
+			contents: "<!-- pandoctor: table source=data.txt -->\n<!-- pandoctor: end -->\n",
+			want:     "<!-- pandoctor: could not expand: can't tell what kind of file data.txt is from its extension (use csv= for a CSV or TSV file, or data= for a YAML or JSON file) -->\n\n<!-- pandoctor: table source=data.txt -->\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "two sources",
+			contents: "<!-- pandoctor: table source=data.csv data=data.yaml -->\n<!-- pandoctor: end -->\n",
+			want:     "<!-- pandoctor: could not expand: a table can only have one of source=, csv= and data= -->\n\n<!-- pandoctor: table source=data.csv data=data.yaml -->\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "no end marker",
+			contents: "<!-- pandoctor: table csv=data.csv -->\n",
+			want:     "<!-- pandoctor: could not expand: table marker has no matching end marker -->\n\n<!-- pandoctor: table csv=data.csv -->\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandTables([]byte(tc.contents))
+			if err != nil {
+				t.Fatalf("expandTables() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("expandTables() diff (-want +got)\n%v", diff)
+			}
+			// Expanding the tables again doesn't change anything.
+			again, err := expandTables(got)
+			if err != nil {
+				t.Fatalf("expandTables() = %v", err)
+			}
+			if diff := cmp.Diff(string(got), string(again)); diff != "" {
+				t.Errorf("second expandTables() diff (-first +second)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestExpandDataTables(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"regs.yaml": `registers:
+  - offset: 0
+    name: CTRL
+  - offset: 4
+    name: STATUS
+`,
+		"regs.table.yaml": `caption: Widget registers
+id: tbl-regs
+columns:
+  - heading: Offset
+    value: '{{printf "0x%02X" .offset}}'
+  - heading: Name
+    value: '{{.name}}'
+`,
+		"regs.json": `{"registers": [{"offset": 0, "name": "CTRL"}, {"offset": 4, "name": "STATUS"}]}`,
+		"regs.txt":  `[{"offset": 0, "name": "CTRL"}, {"offset": 4, "name": "STATUS"}]`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setFlag(t, "file", filepath.Join(dir, "doc.md"))
+	templateTable := `Table: Widget registers {#tbl-regs}
+
++--------+--------+
+| Offset | Name   |
++========+========+
+| 0x00   | CTRL   |
++--------+--------+
+| 0x04   | STATUS |
++--------+--------+
+`
+	fieldsTable := `+--------+--------+
+| offset | name   |
++========+========+
+| 0      | CTRL   |
++--------+--------+
+| 4      | STATUS |
++--------+--------+
+`
+	for _, tc := range []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{
+			name:     "yaml marker with a template",
+			contents: "<!-- pandoctor:table source=regs.yaml records=registers template=regs.table.yaml -->\n<!-- pandoctor:end -->\n",
+			want:     "<!-- pandoctor:table source=regs.yaml records=registers template=regs.table.yaml -->\n\n" + templateTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "yaml marker refreshed",
+			contents: "<!-- pandoctor: table source=regs.yaml records=registers template=regs.table.yaml -->\n\n| old |\n\n<!-- pandoctor: end -->\n",
+			want:     "<!-- pandoctor: table source=regs.yaml records=registers template=regs.table.yaml -->\n\n" + templateTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "json fence",
+			contents: "```{.table source=regs.json records=registers}\n```\n",
+			want:     "<!-- pandoctor: table source=regs.json records=registers -->\n\n" + fieldsTable + "\n<!-- pandoctor: end -->\n",
+		},
+		{
+			name:     "data with another extension",
+			contents: "<!-- pandoctor:table data=regs.txt -->\n<!-- pandoctor:end -->\n",
+			want:     "<!-- pandoctor:table data=regs.txt -->\n\n" + fieldsTable + "\n<!-- pandoctor: end -->\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandTables([]byte(tc.contents))
+			if err != nil {
+				t.Fatalf("expandTables() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("expandTables() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	extractFormat = flag.String("extract_format", "csv", "format of the files written by extract_tables: csv or json")
+	outputDir     = flag.String("output_dir", "", "directory for the files written by extract_tables (default: the directory containing --file)")
+	spans         = flag.String("spans", "blank", "how extract_tables fills the positions covered by a cell that spans several rows or columns: blank or repeat")
+)
+
+// An extractedTable is the data of a table in a document.
+type extractedTable struct {
+	ID      string `json:"id,omitempty"`
+	Caption string `json:"caption,omitempty"`
+	// The text of the cells of the header rows and the body rows, with spans expanded.
+	Header [][]string `json:"header"`
+	Rows   [][]string `json:"rows"`
+}
+
+func validateExtractTablesArgs() error {
+	if *extractFormat != "csv" && *extractFormat != "json" {
+		return fmt.Errorf("--extract_format must be csv or json, not %q", *extractFormat)
+	}
+	if *spans != "blank" && *spans != "repeat" {
+		return fmt.Errorf("--spans must be blank or repeat, not %q", *spans)
+	}
+	return nil
+}
+
+// extractTables writes the data of each grid and HTML table in the document to a file in --output_dir, named after
+// the table's ID (or its position in the document, if it has no ID). Tables that can't be read are reported and
+// skipped.
+func extractTables(contents []byte) error {
+	if err := validateExtractTablesArgs(); err != nil {
+		return err
+	}
+	dir := *outputDir
+	if dir == "" {
+		dir = filepath.Dir(*file)
+	}
+	blocks := findGridTables(contents)
+	html := make(map[int]bool)
+	for _, block := range findHTMLTables(contents) {
+		html[block.start] = true
+		blocks = append(blocks, block)
+	}
+	slices.SortFunc(blocks, func(a, b tableBlock) int {
+		return a.start - b.start
+	})
+
+	used := make(map[string]bool)
+	failed := 0
+	for n, block := range blocks {
+		var table *extractedTable
+		var err error
+		if html[block.start] {
+			table, err = extractHTMLTable([]byte(gridtable.StripPrefix(string(block.text(contents)), block.prefix(contents))))
+		} else {
+			table, err = extractGridTable(block.text(contents))
+		}
+		if err != nil {
+			failed++
+			if !*ignoreErrors {
+				reportTableError(contents, block.tableStart, tableErrorInFile(contents, block.tableStart, err))
+			}
+			continue
+		}
+		name := fmt.Sprintf("table%d", n+1)
+		if table.ID != "" {
+			name = safeFileNameRe.ReplaceAllString(table.ID, "_")
+		}
+		for used[name] {
+			name = fmt.Sprintf("%v_%d", name, n+1)
+		}
+		used[name] = true
+		path := filepath.Join(dir, name+"."+*extractFormat)
+		if err := writeExtractedTable(path, table); err != nil {
+			return err
+		}
+		fmt.Printf("%v:%d: wrote %v\n", *file, lineNumber(contents, block.tableStart), path)
+	}
+	if failed != 0 && !*ignoreErrors {
+		return fmt.Errorf("could not extract %d of %d tables", failed, len(blocks))
+	}
+	return nil
+}
+
+// safeFileNameRe matches the characters that are replaced when a table ID is used as a file name.
+var safeFileNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func writeExtractedTable(path string, table *extractedTable) error {
+	var buf bytes.Buffer
+	switch *extractFormat {
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(table); err != nil {
+			return err
+		}
+	default:
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(append(slices.Clone(table.Header), table.Rows...)); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// extractGridTable reads the data of a grid table block.
+func extractGridTable(text []byte) (*extractedTable, error) {
+	block, err := gridtable.ReadBlock(string(text))
+	if err != nil {
+		return nil, err
+	}
+	table, err := gridtable.NewTable(block.Config, block.Rows)
+	if err != nil {
+		return nil, err
+	}
+	var result extractedTable
+	if block.Caption != nil {
+		result.ID = block.Caption.Attributes.ID
+		result.Caption = block.Caption.Text
+	}
+	result.Header, result.Rows = expandSpans(table)
+	return &result, nil
+}
+
+// extractHTMLTable reads the data of an HTML table.
+func extractHTMLTable(text []byte) (*extractedTable, error) {
+	html, err := parseHTMLTable(text)
+	if err != nil {
+		return nil, err
+	}
+	table, err := html.grid()
+	if err != nil {
+		return nil, err
+	}
+	result := extractedTable{
+		ID:      html.caption.Attributes.ID,
+		Caption: html.caption.Text,
+	}
+	result.Header, result.Rows = expandSpans(table)
+	return &result, nil
+}
+
+// expandSpans returns the text of each position in the header and body of the table. Positions covered by a spanning
+// cell are blank, or repeat the cell's text if --spans=repeat.
+func expandSpans(table *gridtable.Table) ([][]string, [][]string) {
+	var rows [][]string
+	for i := range table.NumRows() {
+		row := make([]string, table.NumColumns())
+		for j := range row {
+			oi, oj, err := table.Owner(i, j)
+			if err != nil || ((oi != i || oj != j) && *spans != "repeat") {
+				continue
+			}
+			row[j] = strings.Join(strings.Fields(table.Cell(oi, oj).Text), " ")
+		}
+		rows = append(rows, row)
+	}
+	numHeaderRows := table.Config().NumHeaderRows
+	return rows[:numHeaderRows:numHeaderRows], rows[numHeaderRows:]
+}
+
+// grid lays out the cells of the HTML table in a grid, the way a browser would: each cell goes in the first column of
+// its row that isn't covered by a row span from above. Missing cells at the ends of rows are filled in with empty
+// cells. All of the columns are as narrow as possible.
+func (t *htmlTable) grid() (*gridtable.Table, error) {
+	rows := append(slices.Clone(t.head), t.body...)
+	numColumns := 0
+	for _, row := range rows {
+		n := 0
+		for _, td := range row {
+			n += max(td.colSpan, 1)
+		}
+		numColumns = max(numColumns, n)
+	}
+	if numColumns == 0 {
+		return nil, fmt.Errorf("table has no cells")
+	}
+	grid := make([][]*gridtable.Cell, len(rows))
+	covered := make([][]bool, len(rows))
+	for i := range rows {
+		grid[i] = make([]*gridtable.Cell, numColumns)
+		covered[i] = make([]bool, numColumns)
+	}
+	for i, row := range rows {
+		j := 0
+		for _, td := range row {
+			for j < numColumns && covered[i][j] {
+				j++
+			}
+			// Browsers truncate row spans at the end of the table (or the header).
+			end := len(rows)
+			if i < len(t.head) {
+				end = len(t.head)
+			}
+			rowSpan, colSpan := min(max(td.rowSpan, 1), end-i), max(td.colSpan, 1)
+			if j+colSpan > numColumns {
+				return nil, fmt.Errorf("row %d has more than %d columns", i+1, numColumns)
+			}
+			grid[i][j] = &gridtable.Cell{
+				Text:    flatten(td.node),
+				RowSpan: rowSpan - 1,
+				ColSpan: colSpan - 1,
+			}
+			for di := range rowSpan {
+				for dj := range colSpan {
+					if covered[i+di][j+dj] {
+						return nil, fmt.Errorf("cell in row %d overlaps a cell that spans from an earlier row", i+1)
+					}
+					covered[i+di][j+dj] = true
+				}
+			}
+			j += colSpan
+		}
+		for j := range numColumns {
+			if !covered[i][j] {
+				grid[i][j] = &gridtable.Cell{}
+				covered[i][j] = true
+			}
+		}
+	}
+	config := gridtable.Config{
+		NumHeaderRows: len(t.head),
+		Columns:       make([]gridtable.ColumnSpec, numColumns),
+	}
+	for j := range config.Columns {
+		config.Columns[j].Width = 3
+	}
+	return gridtable.NewTable(config, grid)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const extractDoc = `Table: Registers {#tbl-regs}
+
++--------+--------+
+| Offset | Name   |
++========+========+
+| 0x00   | CTRL   |
++--------+--------+
+
+> <table id="tbl-quoted">
+> <caption>Quoted</caption>
+> <thead><tr><th>A</th><th>B</th></tr></thead>
+> <tr><td>1
+> <span>and more</span></td><td>2</td></tr>
+> </table>
+
++-----+-----+
+| A   | B   |
++=====+=====+
+| Both      |
++-----+-----+
+| 1   | 2   |
++-----+-----+
+`
+
+func TestExtractTables(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		spans string
+		want  map[string]string
+	}{
+		{
+			name:  "blank spans",
+			spans: "blank",
+			want: map[string]string{
+				"tbl-regs.csv":   "Offset,Name\n0x00,CTRL\n",
+				"tbl-quoted.csv": "A,B\n1 and more,2\n",
+				"table3.csv":     "A,B\nBoth,\n1,2\n",
+			},
+		},
+		{
+			name:  "repeated spans",
+			spans: "repeat",
+			want: map[string]string{
+				"tbl-regs.csv":   "Offset,Name\n0x00,CTRL\n",
+				"tbl-quoted.csv": "A,B\n1 and more,2\n",
+				"table3.csv":     "A,B\nBoth,Both\n1,2\n",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			setFlag(t, "file", filepath.Join(dir, "doc.md"))
+			setFlag(t, "spans", tc.spans)
+			if err := extractTables([]byte(extractDoc)); err != nil {
+				t.Fatalf("extractTables() = %v", err)
+			}
+			got := make(map[string]string)
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				contents, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[entry.Name()] = string(contents)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("extractTables() files diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestExtractTablesJSON(t *testing.T) {
+	dir := t.TempDir()
+	setFlag(t, "file", filepath.Join(dir, "doc.md"))
+	setFlag(t, "extract_format", "json")
+	if err := extractTables([]byte(extractDoc)); err != nil {
+		t.Fatalf("extractTables() = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "tbl-quoted.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "id": "tbl-quoted",
+  "caption": "Quoted",
+  "header": [
+    [
+      "A",
+      "B"
+    ]
+  ],
+  "rows": [
+    [
+      "1 and more",
+      "2"
+    ]
+  ]
+}
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("tbl-quoted.json diff (-want +got)\n%v", diff)
+	}
+}
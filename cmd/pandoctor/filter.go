@@ -0,0 +1,395 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/chrisfenner/pandoctor/pkg/pandoc"
+	"github.com/chrisfenner/pandoctor/pkg/pandocgrid"
+	xhtml "golang.org/x/net/html"
+)
+
+var (
+	gridTables = flag.Bool("grid_tables", false, "when running as a Pandoc filter with Markdown output, replace the tables with grid tables laid out by pandoctor")
+)
+
+// runFilter runs pandoctor as a Pandoc JSON filter: it reads a Pandoc AST from r, converts any HTML tables into Table
+// nodes, expands table directives, resizes any Table nodes that match a resize rule, and writes the AST to w.
+// format is the output format Pandoc is producing, or "" if unknown.
+// Errors with individual tables are printed to stderr (unless --ignore_errors is set) and the table is left as-is.
+func runFilter(r io.Reader, w io.Writer, format string) error {
+	doc, err := pandoc.ReadDocument(r)
+	if err != nil {
+		return err
+	}
+	resize := len(resizeRules) != 0
+	// Raw Markdown blocks are dropped from any other output format.
+	grid := *gridTables && (format == "" || strings.HasPrefix(format, "markdown"))
+	err = doc.WalkBlockLists(func(blocks []*pandoc.Element) ([]*pandoc.Element, error) {
+		blocks = convertHTMLTableBlocks(blocks)
+		for _, block := range blocks {
+			if err := expandTableCodeBlock(block); err != nil {
+				reportFilterError("expand_tables", err)
+			}
+			if resize {
+				if err := resizePandocTable(block); err != nil {
+					reportFilterError("resize_tables", err)
+				}
+			}
+			if grid {
+				if err := gridTableBlock(block); err != nil {
+					reportFilterError("convert_tables", err)
+				}
+			}
+		}
+		return blocks, nil
+	})
+	if err != nil {
+		return err
+	}
+	return doc.Write(w)
+}
+
+// reportFilterError prints an error about a table that could not be processed in filter mode.
+func reportFilterError(action string, err error) {
+	if !*ignoreErrors {
+		fmt.Fprintf(os.Stderr, "pandoctor: could not %v table: %v\n", annotationVerb(action), err)
+	}
+}
+
+// convertHTMLTableBlocks replaces the HTML tables in the list of blocks with Table nodes.
+// Depending on the input format, Pandoc represents an HTML table either as a single RawBlock, or as a run of blocks
+// from the RawBlock that opens the table to the RawBlock that closes it, with the contents of the cells parsed as
+// Markdown in between.
+func convertHTMLTableBlocks(blocks []*pandoc.Element) []*pandoc.Element {
+	var result []*pandoc.Element
+	for i := 0; i < len(blocks); i++ {
+		format, text, ok := blocks[i].RawBlockText()
+		if !ok || format != "html" || !strings.HasPrefix(strings.ToLower(strings.TrimSpace(text)), "<table") {
+			result = append(result, blocks[i])
+			continue
+		}
+		end := i
+		for ; end < len(blocks); end++ {
+			if format, text, ok := blocks[end].RawBlockText(); ok && format == "html" && strings.Contains(strings.ToLower(text), "</table>") {
+				break
+			}
+		}
+		if end == len(blocks) {
+			// The table was never closed, so leave it alone.
+			result = append(result, blocks[i])
+			continue
+		}
+		table, err := htmlTableElement([]byte(blocksToHTML(blocks[i : end+1])))
+		if err != nil {
+			reportFilterError("convert_tables", err)
+			result = append(result, blocks[i:end+1]...)
+		} else {
+			result = append(result, table)
+		}
+		i = end
+	}
+	return result
+}
+
+// htmlTableElement converts an HTML table into a Pandoc Table element.
+func htmlTableElement(contents []byte) (*pandoc.Element, error) {
+	table, err := parseHTMLTable(contents)
+	if err != nil {
+		return nil, err
+	}
+	result := pandoc.Table{
+		Attr: pandoc.Attr{
+			ID:      table.caption.Attributes.ID,
+			Classes: table.caption.Attributes.Classes,
+		},
+	}
+	if table.caption.Text != "" {
+		result.Caption.Long = []*pandoc.Element{pandoc.Plain(pandoc.Text(table.caption.Text))}
+	}
+
+	// The column widths are only meaningful if the table had a colgroup with the right number of columns.
+	var firstRow []htmlCell
+	if len(table.head) != 0 {
+		firstRow = table.head[0]
+	} else if len(table.body) != 0 {
+		firstRow = table.body[0]
+	}
+	numColumns := 0
+	for _, cell := range firstRow {
+		numColumns += max(cell.colSpan, 1)
+	}
+	if table.hasColgroup && numColumns == len(table.config.Columns) {
+		result.ColSpecs = pandocgrid.ColSpecs(table.config.Columns, *tableWidth)
+	} else {
+		result.ColSpecs = make([]pandoc.ColSpec, numColumns)
+	}
+
+	result.Head.Rows = pandocRows(table.head)
+	result.Bodies = []pandoc.TableBody{{
+		Rows: pandocRows(table.body),
+	}}
+	return result.Element(), nil
+}
+
+// pandocRows converts rows of HTML cells into Pandoc rows.
+func pandocRows(rows [][]htmlCell) []pandoc.Row {
+	var result []pandoc.Row
+	for _, row := range rows {
+		var cells []pandoc.Cell
+		for _, cell := range row {
+			cells = append(cells, pandoc.Cell{
+				RowSpan: max(cell.rowSpan, 1),
+				ColSpan: max(cell.colSpan, 1),
+				Blocks:  htmlBlocks(cell.node),
+			})
+		}
+		result = append(result, pandoc.Row{Cells: cells})
+	}
+	return result
+}
+
+// htmlBlocks converts the contents of an HTML element into Pandoc blocks. Each p element becomes a Para, and any other
+// content becomes a Plain.
+func htmlBlocks(node *xhtml.Node) []*pandoc.Element {
+	var result []*pandoc.Element
+	var inlines []*pandoc.Element
+	flush := func() {
+		if inlines = trimSpaces(inlines); len(inlines) != 0 {
+			result = append(result, pandoc.Plain(inlines))
+		}
+		inlines = nil
+	}
+	for child := range children(node) {
+		if child.Type == xhtml.ElementNode && child.Data == "p" {
+			flush()
+			result = append(result, pandoc.Para(trimSpaces(htmlInlines(child))))
+			continue
+		}
+		inlines = append(inlines, htmlInlines(child)...)
+	}
+	flush()
+	return result
+}
+
+// htmlInlineTypes maps HTML elements to the Pandoc inlines they become.
+var htmlInlineTypes = map[string]string{
+	"em":     "Emph",
+	"i":      "Emph",
+	"strong": "Strong",
+	"b":      "Strong",
+	"u":      "Underline",
+	"s":      "Strikeout",
+	"del":    "Strikeout",
+	"sup":    "Superscript",
+	"sub":    "Subscript",
+}
+
+// htmlInlines converts an HTML node into Pandoc inlines. Elements without a Pandoc equivalent are replaced by their
+// contents.
+func htmlInlines(node *xhtml.Node) []*pandoc.Element {
+	switch node.Type {
+	case xhtml.TextNode:
+		return textInlines(node.Data)
+	case xhtml.ElementNode:
+	default:
+		return nil
+	}
+	switch node.Data {
+	case "br":
+		return []*pandoc.Element{pandoc.NewElement("LineBreak", nil)}
+	case "code":
+		return []*pandoc.Element{pandoc.NewElement("Code", []any{pandoc.Attr{}, flatten(node)})}
+	}
+	var contents []*pandoc.Element
+	for child := range children(node) {
+		contents = append(contents, htmlInlines(child)...)
+	}
+	if t, ok := htmlInlineTypes[node.Data]; ok {
+		return []*pandoc.Element{pandoc.NewElement(t, trimSpaces(contents))}
+	}
+	return contents
+}
+
+// textInlines converts text into Str and Space inlines, keeping any leading or trailing whitespace as a Space.
+func textInlines(text string) []*pandoc.Element {
+	if strings.TrimSpace(text) == "" {
+		if text == "" {
+			return nil
+		}
+		return []*pandoc.Element{pandoc.Space()}
+	}
+	var result []*pandoc.Element
+	if unicode.IsSpace(rune(text[0])) {
+		result = append(result, pandoc.Space())
+	}
+	result = append(result, pandoc.Text(text)...)
+	if unicode.IsSpace(rune(text[len(text)-1])) {
+		result = append(result, pandoc.Space())
+	}
+	return result
+}
+
+// trimSpaces removes leading, trailing and repeated Spaces from a list of inlines.
+func trimSpaces(inlines []*pandoc.Element) []*pandoc.Element {
+	result := []*pandoc.Element{}
+	for _, inline := range inlines {
+		if inline.T == "Space" && (len(result) == 0 || result[len(result)-1].T == "Space") {
+			continue
+		}
+		result = append(result, inline)
+	}
+	if len(result) != 0 && result[len(result)-1].T == "Space" {
+		result = result[:len(result)-1]
+	}
+	return result
+}
+
+// blocksToHTML reconstructs the HTML of a table that Pandoc has split into several blocks.
+func blocksToHTML(blocks []*pandoc.Element) string {
+	var sb strings.Builder
+	for _, block := range blocks {
+		if format, text, ok := block.RawBlockText(); ok {
+			if format == "html" {
+				sb.WriteString(text)
+			}
+			continue
+		}
+		// The contents of the cells are usually just a Plain or Para.
+		switch block.T {
+		case "Para":
+			sb.WriteString("<p>")
+			writeInlinesHTML(&sb, block.Children())
+			sb.WriteString("</p>")
+		case "Plain":
+			writeInlinesHTML(&sb, block.Children())
+		default:
+			sb.WriteString(html.EscapeString(pandoc.Stringify([]*pandoc.Element{block})))
+		}
+	}
+	return sb.String()
+}
+
+// writeInlinesHTML writes Pandoc inlines as HTML. Only the formatting that htmlInlines understands is kept.
+func writeInlinesHTML(sb *strings.Builder, inlines []*pandoc.Element) {
+	for _, inline := range inlines {
+		switch inline.T {
+		case "Space", "SoftBreak":
+			sb.WriteString(" ")
+		case "LineBreak":
+			sb.WriteString("<br>")
+		case "Emph", "Strong":
+			tag := map[string]string{"Emph": "em", "Strong": "strong"}[inline.T]
+			sb.WriteString("<" + tag + ">")
+			writeInlinesHTML(sb, inline.Children())
+			sb.WriteString("</" + tag + ">")
+		case "Code":
+			sb.WriteString("<code>" + html.EscapeString(pandoc.Stringify([]*pandoc.Element{inline})) + "</code>")
+		case "RawInline":
+			if format, text, ok := inline.RawText(); ok && format == "html" {
+				sb.WriteString(text)
+			}
+		default:
+			sb.WriteString(html.EscapeString(pandoc.Stringify([]*pandoc.Element{inline})))
+		}
+	}
+}
+
+// gridTableBlock replaces the given block with a grid table in a raw Markdown block, if it is a Table.
+func gridTableBlock(elem *pandoc.Element) error {
+	table, isTable, err := elem.Table()
+	if !isTable || err != nil {
+		return err
+	}
+	block, err := pandocgrid.FromTable(table, *tableWidth)
+	if err != nil {
+		return err
+	}
+	captionWidth := 0
+	if *wrapCaptions {
+		captionWidth = block.Width()
+	}
+	text, err := block.Format(captionWidth)
+	if err != nil {
+		return err
+	}
+	*elem = *pandoc.RawBlock("markdown", strings.TrimSuffix(text, "\n"))
+	return nil
+}
+
+// resizePandocTable applies the first resize rule that matches the given block, if it is a Table.
+func resizePandocTable(elem *pandoc.Element) error {
+	table, isTable, err := elem.Table()
+	if !isTable || err != nil {
+		return err
+	}
+	block := pandocTableBlock(table)
+	var rule *resizeRule
+	for i := range resizeRules {
+		if resizeRules[i].match(block) {
+			rule = &resizeRules[i]
+			break
+		}
+	}
+	if rule == nil {
+		return nil
+	}
+	if err := rule.apply(&block.Config); err != nil {
+		return err
+	}
+	for i, spec := range pandocgrid.ColSpecs(block.Config.Columns, rule.width()) {
+		table.ColSpecs[i].Width = spec.Width
+	}
+	*elem = *table.Element()
+	return nil
+}
+
+// pandocTableBlock describes a Pandoc table as a grid table block, with just enough information to match and resize
+// it: the widths of its columns (relative to --table_width), its caption and its first row.
+func pandocTableBlock(table *pandoc.Table) *gridtable.Block {
+	block := gridtable.Block{
+		Config: gridtable.Config{
+			NumHeaderRows: len(table.Head.Rows),
+			Columns:       pandocgrid.ColumnSpecs(table.ColSpecs, *tableWidth),
+		},
+	}
+	if len(table.Caption.Long) != 0 || !table.Attr.IsEmpty() {
+		block.Caption = &gridtable.Caption{
+			Text: pandoc.Stringify(table.Caption.Long),
+			Attributes: gridtable.Attributes{
+				ID:      table.Attr.ID,
+				Classes: table.Attr.Classes,
+				KeyVals: table.Attr.KeyVals,
+			},
+		}
+		block.CaptionPosition = gridtable.CaptionAfter
+	}
+	var firstRow *pandoc.Row
+	if len(table.Head.Rows) != 0 {
+		firstRow = &table.Head.Rows[0]
+	} else if len(table.Bodies) != 0 && len(table.Bodies[0].Rows) != 0 {
+		firstRow = &table.Bodies[0].Rows[0]
+	}
+	if firstRow != nil {
+		var row []*gridtable.Cell
+		for _, cell := range firstRow.Cells {
+			row = append(row, &gridtable.Cell{
+				Text:    pandoc.Stringify(cell.Blocks),
+				RowSpan: cell.RowSpan - 1,
+				ColSpan: cell.ColSpan - 1,
+			})
+			// Like gridtable.Reader, represent the columns covered by a span as nil.
+			for range cell.ColSpan - 1 {
+				row = append(row, nil)
+			}
+		}
+		block.Rows = [][]*gridtable.Cell{row}
+	}
+	return &block
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMainErrNeedsFile(t *testing.T) {
+	setArgs(t, "fmt_tables")
+	if err := mainErr(); err == nil || !strings.Contains(err.Error(), "--file") {
+		t.Errorf("mainErr() = %v, want an error about --file", err)
+	}
+}
+
+func TestRunFilter(t *testing.T) {
+	oldResizeRules := resizeRules
+	t.Cleanup(func() { resizeRules = oldResizeRules })
+	setFlag(t, "table_width", "80")
+	setFlag(t, "match_columns", "Name,Value")
+	setFlag(t, "new_widths", "25%,75%")
+	if err := validateResizeTablesArgs(); err != nil {
+		t.Fatalf("validateResizeTablesArgs() = %v", err)
+	}
+	const (
+		htmlTable = `{"t":"RawBlock","c":["html","<table>\n<thead><tr><th>A</th></tr></thead>\n<tr><td>1</td></tr>\n</table>"]}`
+		table     = `{"t":"Table","c":[["",[],[]],[null,[]],[[{"t":"AlignDefault"},{"t":"ColWidthDefault"}],[{"t":"AlignDefault"},{"t":"ColWidthDefault"}]],[["",[],[]],[[["",[],[]],[[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"Name"}]}]],[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"Value"}]}]]]]]],[[["",[],[]],0,[],[[["",[],[]],[[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"x"}]}]],[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"1"}]}]]]]]]],[["",[],[]],[]]]}`
+		// The HTML table becomes a Table node.
+		convertedTable = `{"t":"Table","c":[["",[],[]],[null,[]],[[{"t":"AlignDefault"},{"t":"ColWidthDefault"}]],[["",[],[]],[[["",[],[]],[[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"A"}]}]]]]]],[[["",[],[]],0,[],[[["",[],[]],[[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"1"}]}]]]]]]],[["",[],[]],[]]]}`
+		// The table matches the resize rule, so its columns get explicit widths.
+		resizedTable = `{"t":"Table","c":[["",[],[]],[null,[]],[[{"t":"AlignDefault"},{"t":"ColWidth","c":0.24675324675324675}],[{"t":"AlignDefault"},{"t":"ColWidth","c":0.7402597402597403}]],[["",[],[]],[[["",[],[]],[[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"Name"}]}]],[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"Value"}]}]]]]]],[[["",[],[]],0,[],[[["",[],[]],[[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"x"}]}]],[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Plain","c":[{"t":"Str","c":"1"}]}]]]]]]],[["",[],[]],[]]]}`
+	)
+	input := `{"pandoc-api-version":[1,23,1],"meta":{},"blocks":[` + htmlTable + "," + table + "]}"
+	var got bytes.Buffer
+	if err := runFilter(strings.NewReader(input), &got, "html"); err != nil {
+		t.Fatalf("runFilter() = %v", err)
+	}
+	want := `{"pandoc-api-version":[1,23,1],"meta":{},"blocks":[` + convertedTable + "," + resizedTable + "]}\n"
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("runFilter() diff (-want +got)\n%v", diff)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+// fmtTables re-renders every grid table with its current column widths, so that hand-edited tables are canonical.
+func fmtTables(contents []byte) ([]byte, error) {
+	return processTables(contents, "fmt_tables", findTables(contents, lenientGridTableRe), fmtGridTable), nil
+}
+
+// fmtGridTable re-renders the table. Lines that are only the wrong width (e.g. a ragged right border) are fixed like
+// repair_tables would; tables with other mistakes are left to repair_tables.
+func fmtGridTable(contents []byte, loc tableBlock) ([]byte, string, error) {
+	block, err := gridtable.ReadBlock(string(loc.text(contents)))
+	if errors.Is(err, gridtable.ErrMalformedTable) {
+		if lenient, repairs, lenientErr := gridtable.ReadLenientBlock(string(loc.text(contents))); lenientErr == nil && onlyLineWidths(repairs) {
+			block, err = lenient, nil
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	result, status, err := renderBlock(contents, loc, block)
+	if errors.Is(err, gridtable.ErrBadWrap) {
+		return nil, "", fmt.Errorf("content no longer fits: %w", err)
+	}
+	return result, status, err
+}
+
+// onlyLineWidths returns whether all of the repairs only fixed the widths of lines.
+func onlyLineWidths(repairs []gridtable.Repair) bool {
+	for _, repair := range repairs {
+		if !repair.LineWidth {
+			return false
+		}
+	}
+	return true
+}
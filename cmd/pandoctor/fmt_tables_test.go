@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFmtTables(t *testing.T) {
+	formatted := `+----+----+
+| A  | B  |
++====+====+
+| 1  | 2  |
+| 3  | 4  |
++----+----+
+`
+	for _, tc := range []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{
+			name:     "already formatted",
+			contents: formatted,
+			want:     formatted,
+		},
+		{
+			name: "padding",
+			contents: `+----+----+
+|A   |   B|
++====+====+
+|  1 | 2  |
+| 3  |4   |
++----+----+
+`,
+			want: formatted,
+		},
+		{
+			name: "ragged right border",
+			contents: "+----+----+\n" +
+				"| A | B |\n" +
+				"+====+====+\n" +
+				"| 1  | 2    |\n" +
+				"| 3  | 4\n" +
+				"+----+----+  \n",
+			want: formatted,
+		},
+		{
+			name: "column widths changed",
+			contents: `+----+----+
+| A  | B  |
++====+====+
+| 1  | 2  |
+| 3  | 4  |
++----+-----+
+`,
+			want: `<!-- pandoctor: could not fmt: malformed grid table: width of column 1 appeared to change midway through this table -->
+
++----+----+
+| A  | B  |
++====+====+
+| 1  | 2  |
+| 3  | 4  |
++----+-----+
+`,
+		},
+		{
+			name: "missing cell",
+			contents: `+----+----+
+| A  | B  |
++====+====+
+| 1  |
++----+----+
+`,
+			want: `<!-- pandoctor: could not fmt: malformed grid table: each line of text needs to have the same width (expected 11 characters, got 6) -->
+
++----+----+
+| A  | B  |
++====+====+
+| 1  |
++----+----+
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := fmtTables([]byte(tc.contents))
+			if err != nil {
+				t.Fatalf("fmtTables() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("fmtTables() diff (-want +got)\n%v", diff)
+			}
+			// Formatting the tables again doesn't change anything.
+			again, err := fmtTables(got)
+			if err != nil {
+				t.Fatalf("fmtTables() = %v", err)
+			}
+			if diff := cmp.Diff(string(got), string(again)); diff != "" {
+				t.Errorf("second fmtTables() diff (-first +second)\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"gopkg.in/yaml.v3"
+)
+
+// ignoreRules match the tables that actions leave alone.
+var ignoreRules []tableMatcher
+
+// frontMatter returns the YAML metadata block at the start of the document (without its delimiters) and the offset
+// just after it, or 0 if the document doesn't start with one. Like Pandoc's, the block starts with a line of "---"
+// that isn't followed by a blank line (which would make it a horizontal rule) and ends with a line of "---" or "...".
+func frontMatter(contents []byte) ([]byte, int) {
+	line, next := nextLine(contents, 0)
+	if string(bytes.TrimRight(line, " \t\r")) != "---" {
+		return nil, 0
+	}
+	if line, _ := nextLine(contents, next); len(bytes.TrimSpace(line)) == 0 {
+		return nil, 0
+	}
+	for offset := next; offset < len(contents); {
+		line, end := nextLine(contents, offset)
+		if delim := string(bytes.TrimRight(line, " \t\r")); delim == "---" || delim == "..." {
+			return contents[next:offset], end
+		}
+		offset = end
+	}
+	return nil, 0
+}
+
+// frontMatterEnd returns the offset just after the YAML metadata block at the start of the document, or 0 if there
+// isn't one.
+func frontMatterEnd(contents []byte) int {
+	_, end := frontMatter(contents)
+	return end
+}
+
+// documentConfig returns the settings under the "pandoctor" key of the document's YAML metadata block, if any.
+func documentConfig(contents []byte) (*projectConfig, error) {
+	meta, _ := frontMatter(contents)
+	if meta == nil {
+		return nil, nil
+	}
+	var doc struct {
+		Pandoctor *projectConfig `yaml:"pandoctor"`
+	}
+	if err := yaml.Unmarshal(meta, &doc); err != nil {
+		return nil, fmt.Errorf("could not parse the metadata of %v: %w", *file, err)
+	}
+	if doc.Pandoctor == nil {
+		return nil, nil
+	}
+	if err := doc.Pandoctor.validate(*file + " metadata"); err != nil {
+		return nil, err
+	}
+	return doc.Pandoctor, nil
+}
+
+// applyDocumentConfig uses the settings in the document's metadata to fill in any settings that weren't explicitly
+// provided on the command line. They take precedence over the project configuration file.
+func applyDocumentConfig(contents []byte) error {
+	config, err := documentConfig(contents)
+	if err != nil || config == nil {
+		return err
+	}
+	set := setFlags()
+	if config.TableWidth != nil && !set["table_width"] {
+		*tableWidth = *config.TableWidth
+	}
+	if config.IgnoreErrors != nil && !set["ignore_errors"] {
+		*ignoreErrors = *config.IgnoreErrors
+	}
+	resizeRules = append(config.ResizeRules, resizeRules...)
+	ignoreRules = append(ignoreRules, config.Ignore...)
+	return nil
+}
+
+// ignored returns whether the table in the block matches any of the ignore rules.
+func ignored(contents []byte, loc tableBlock) bool {
+	if len(ignoreRules) == 0 {
+		return false
+	}
+	block, err := gridtable.ReadBlock(string(loc.text(contents)))
+	if err != nil {
+		// It may be an HTML table.
+		html, err := parseHTMLTable([]byte(gridtable.StripPrefix(string(loc.text(contents)), loc.prefix(contents))))
+		if err != nil {
+			return false
+		}
+		table, err := html.grid()
+		if err != nil {
+			return false
+		}
+		block = &gridtable.Block{Config: table.Config(), Rows: table.Rows(), Caption: &html.caption}
+	}
+	for i := range ignoreRules {
+		if ignoreRules[i].match(block) {
+			return true
+		}
+	}
+	return false
+}
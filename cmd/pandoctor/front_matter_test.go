@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var frontMatterTests = []struct {
+	name     string
+	contents string
+	// The metadata block, or "" if there isn't one, and the offset just after it.
+	want string
+	end  int
+}{
+	{
+		name:     "no front matter",
+		contents: "# Title\n\npandoctor:\n  ignore_errors: true\n",
+	},
+	{
+		name:     "closed by ---",
+		contents: "---\npandoctor:\n  ignore_errors: true\n---\n\n# Title\n",
+		want:     "pandoctor:\n  ignore_errors: true\n",
+		end:      41,
+	},
+	{
+		name:     "closed by ...",
+		contents: "---\npandoctor:\n  ignore_errors: true\n...\n\n# Title\n\n---\n",
+		want:     "pandoctor:\n  ignore_errors: true\n",
+		end:      41,
+	},
+	{
+		name:     "unterminated",
+		contents: "---\npandoctor:\n  ignore_errors: true\n",
+	},
+	{
+		name:     "not at the start",
+		contents: "\n---\npandoctor:\n  ignore_errors: true\n---\n",
+	},
+	{
+		name:     "after a heading",
+		contents: "# Title\n\n---\npandoctor:\n  ignore_errors: true\n---\n",
+	},
+	{
+		name:     "thematic breaks",
+		contents: "---\n\npandoctor:\n  ignore_errors: true\n\n---\n",
+	},
+}
+
+func TestFrontMatter(t *testing.T) {
+	for _, tc := range frontMatterTests {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, end := frontMatter([]byte(tc.contents))
+			if diff := cmp.Diff(tc.want, string(meta)); diff != "" {
+				t.Errorf("frontMatter() diff (-want +got)\n%v", diff)
+			}
+			if end != tc.end {
+				t.Errorf("frontMatter() end = %d, want %d", end, tc.end)
+			}
+		})
+	}
+}
+
+func TestApplyDocumentConfig(t *testing.T) {
+	for _, tc := range frontMatterTests {
+		t.Run(tc.name, func(t *testing.T) {
+			saveRules(t)
+			oldIgnoreErrors := *ignoreErrors
+			t.Cleanup(func() { *ignoreErrors = oldIgnoreErrors })
+			*ignoreErrors = false
+			if err := applyDocumentConfig([]byte(tc.contents)); err != nil {
+				t.Fatalf("applyDocumentConfig() = %v", err)
+			}
+			if want := tc.want != ""; *ignoreErrors != want {
+				t.Errorf("--ignore_errors = %v, want %v", *ignoreErrors, want)
+			}
+		})
+	}
+}
+
+func TestApplyDocumentConfigInvalid(t *testing.T) {
+	saveRules(t)
+	setFlag(t, "file", "doc.md")
+	for _, contents := range []string{
+		"---\npandoctor: [\n---\n",
+		"---\npandoctor:\n  ignore:\n    - caption: 're:('\n---\n",
+	} {
+		if err := applyDocumentConfig([]byte(contents)); err == nil {
+			t.Errorf("applyDocumentConfig(%q) succeeded, want an error", contents)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	listFormat = flag.String("list_format", "text", "output format of list_tables: text or json")
+)
+
+// pipeDelimiterPattern matches the delimiter line of a pipe table, e.g. "|---|:--:|" or "--- | ---". It needs at least
+// one '|', so that it doesn't match a horizontal rule.
+const pipeDelimiterPattern = `[ \t]*(?:\|[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?|:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)+\|?)[ \t]*`
+
+// pipeTableRe matches a pipe table: a header line, a delimiter line and any number of lines containing '|'.
+var pipeTableRe = regexp.MustCompile(`(?m)^[^\n]*\|[^\n]*\n` + pipeDelimiterPattern + `\n(?:[^\n]*\|[^\n]*\n)*`)
+
+// A tableInfo describes a table in a document for list_tables.
+type tableInfo struct {
+	File      string `json:"file"`
+	Kind      string `json:"kind"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Caption   string `json:"caption,omitempty"`
+	ID        string `json:"id,omitempty"`
+	// The number of columns and header rows of the table.
+	Columns    int `json:"columns"`
+	HeaderRows int `json:"header_rows"`
+	// The widths of the columns in characters, if the table has any: the width of each column of a grid table, the
+	// width of each column's delimiter in a pipe table or the widths from an HTML table's colgroup.
+	Widths []int `json:"widths,omitempty"`
+	// The text of the cells in the first row.
+	Headings []string `json:"headings"`
+	// Why the table couldn't be read, if it couldn't.
+	Error string `json:"error,omitempty"`
+}
+
+func validateListTablesArgs() error {
+	if *listFormat != "text" && *listFormat != "json" {
+		return fmt.Errorf("--list_format must be text or json, not %q", *listFormat)
+	}
+	return nil
+}
+
+// listTables prints a description of each table in each of the files.
+func listTables(w io.Writer, files []string) error {
+	if err := validateListTablesArgs(); err != nil {
+		return err
+	}
+	var tables []tableInfo
+	for _, path := range files {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, documentTables(path, contents)...)
+	}
+	if *listFormat == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if tables == nil {
+			tables = []tableInfo{}
+		}
+		return enc.Encode(tables)
+	}
+	for _, table := range tables {
+		if _, err := fmt.Fprintln(w, table.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// documentTables describes the tables in a document, in order.
+func documentTables(path string, contents []byte) []tableInfo {
+	type located struct {
+		kind  string
+		block tableBlock
+	}
+	var tables []located
+	for kind, re := range map[string]*regexp.Regexp{
+		"grid": gridTableRe,
+		"html": htmlTableRe,
+		"pipe": pipeTableRe,
+	} {
+		for _, block := range matchTables(contents, re) {
+			tables = append(tables, located{kind, block})
+		}
+	}
+	slices.SortFunc(tables, func(a, b located) int {
+		return a.block.tableStart - b.block.tableStart
+	})
+	// Find the captions of all the tables together, so that a paragraph between two tables of different kinds is
+	// only the caption of one of them.
+	blocks := make([]tableBlock, len(tables))
+	for i, t := range tables {
+		blocks[i] = t.block
+	}
+	addCaptions(contents, blocks, func(i int) bool { return tables[i].kind != "html" })
+	for i := range tables {
+		tables[i].block = blocks[i]
+	}
+	var result []tableInfo
+	for _, t := range tables {
+		info := tableInfo{
+			File:      path,
+			Kind:      t.kind,
+			StartLine: lineNumber(contents, t.block.tableStart),
+			EndLine:   lineNumber(contents, t.block.tableEnd-1),
+		}
+		var err error
+		switch t.kind {
+		case "grid":
+			err = info.describeGridTable(t.block.text(contents))
+		case "html":
+			err = info.describeHTMLTable(t.block.text(contents))
+		case "pipe":
+			err = info.describePipeTable(contents, t.block)
+		}
+		if err != nil {
+			info.Error = tableErrorInFile(contents, t.block.tableStart, err).Error()
+		}
+		result = append(result, info)
+	}
+	return result
+}
+
+func (info *tableInfo) describeGridTable(text []byte) error {
+	block, err := gridtable.ReadBlock(string(text))
+	if err != nil {
+		return err
+	}
+	info.describeCaption(block.Caption)
+	info.Columns = len(block.Config.Columns)
+	info.HeaderRows = block.Config.NumHeaderRows
+	for _, col := range block.Config.Columns {
+		info.Widths = append(info.Widths, col.Width)
+	}
+	info.Headings = block.Headings()
+	return nil
+}
+
+func (info *tableInfo) describeHTMLTable(text []byte) error {
+	html, err := parseHTMLTable(text)
+	if err != nil {
+		return err
+	}
+	info.describeCaption(&html.caption)
+	table, err := html.grid()
+	if err != nil {
+		return err
+	}
+	info.Columns = table.NumColumns()
+	info.HeaderRows = len(html.head)
+	if html.hasColgroup {
+		for _, col := range html.config.Columns {
+			info.Widths = append(info.Widths, col.Width)
+		}
+	}
+	for j := range table.NumColumns() {
+		heading := ""
+		if cell := table.Cell(0, j); cell != nil {
+			heading = strings.Join(strings.Fields(cell.Text), " ")
+		}
+		info.Headings = append(info.Headings, heading)
+	}
+	return nil
+}
+
+// describePipeTable describes the pipe table in the given block.
+func (info *tableInfo) describePipeTable(contents []byte, loc tableBlock) error {
+	caption := strings.TrimSpace(string(contents[loc.start:loc.tableStart]) + string(contents[loc.tableEnd:loc.end]))
+	if caption != "" {
+		c, err := gridtable.ParseCaption(caption)
+		if err != nil {
+			return err
+		}
+		info.describeCaption(c)
+	}
+	lines := strings.Split(string(contents[loc.tableStart:loc.tableEnd]), "\n")
+	info.HeaderRows = 1
+	for _, cell := range pipeCells(lines[1]) {
+		info.Widths = append(info.Widths, len(cell))
+	}
+	info.Columns = len(info.Widths)
+	info.Headings = pipeCells(lines[0])
+	return nil
+}
+
+// pipeCells splits a line of a pipe table into the (trimmed) text of its cells.
+func pipeCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var result []string
+	for _, cell := range strings.Split(line, "|") {
+		result = append(result, strings.TrimSpace(cell))
+	}
+	return result
+}
+
+func (info *tableInfo) describeCaption(caption *gridtable.Caption) {
+	if caption == nil {
+		return
+	}
+	info.Caption = caption.Text
+	info.ID = caption.Attributes.ID
+}
+
+// String describes the table on a single line, e.g.:
+//
+//	doc.md:3-9: grid columns=3 header_rows=1 widths=6,8,13 id=tbl-fields caption="Fields" headings="Name","Type","Notes"
+func (info *tableInfo) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v:%d-%d: %v", info.File, info.StartLine, info.EndLine, info.Kind)
+	if info.Error != "" {
+		fmt.Fprintf(&sb, " error=%q", info.Error)
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, " columns=%d header_rows=%d", info.Columns, info.HeaderRows)
+	if len(info.Widths) != 0 {
+		var widths []string
+		for _, width := range info.Widths {
+			widths = append(widths, strconv.Itoa(width))
+		}
+		fmt.Fprintf(&sb, " widths=%v", strings.Join(widths, ","))
+	}
+	if info.ID != "" {
+		fmt.Fprintf(&sb, " id=%v", info.ID)
+	}
+	if info.Caption != "" {
+		fmt.Fprintf(&sb, " caption=%q", info.Caption)
+	}
+	var headings []string
+	for _, heading := range info.Headings {
+		headings = append(headings, strconv.Quote(heading))
+	}
+	fmt.Fprintf(&sb, " headings=%v", strings.Join(headings, ","))
+	return sb.String()
+}
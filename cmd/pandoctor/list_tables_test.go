@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDocumentTables(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		contents string
+		want     []string
+	}{
+		{
+			name: "caption between a grid table and a pipe table",
+			contents: `+---+
+| A |
++===+
+| 1 |
++---+
+
+Table: Grid
+
+| B |
+|---|
+| 2 |
+`,
+			want: []string{
+				`doc.md:1-5: grid columns=1 header_rows=1 widths=3 caption="Grid" headings="A"`,
+				`doc.md:9-11: pipe columns=1 header_rows=1 widths=3 headings="B"`,
+			},
+		},
+		{
+			name: "caption between a pipe table and a grid table",
+			contents: `| B |
+|---|
+| 2 |
+
+Table: Pipe
+
++---+
+| A |
++===+
+| 1 |
++---+
+`,
+			want: []string{
+				`doc.md:1-3: pipe columns=1 header_rows=1 widths=3 caption="Pipe" headings="B"`,
+				`doc.md:7-11: grid columns=1 header_rows=1 widths=3 headings="A"`,
+			},
+		},
+		{
+			name: "caption between an HTML table and a pipe table",
+			contents: `<table>
+<tr><td>C</td></tr>
+</table>
+
+: Pipe
+
+| B |
+|---|
+| 2 |
+`,
+			want: []string{
+				`doc.md:1-3: html columns=1 header_rows=0 headings="C"`,
+				`doc.md:7-9: pipe columns=1 header_rows=1 widths=3 caption="Pipe" headings="B"`,
+			},
+		},
+		{
+			name:     "pipe tables in code blocks",
+			contents: "```markdown\n| B |\n|---|\n| 2 |\n```\n\n~~~~\n| C |\n|---|\n~~~~\n\n  ```\n| D |\n|---|\n  ```\n\n| E |\n|---|\n",
+			want: []string{
+				`doc.md:17-18: pipe columns=1 header_rows=1 widths=3 headings="E"`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, table := range documentTables("doc.md", []byte(tc.contents)) {
+				got = append(got, table.String())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("documentTables() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
@@ -7,14 +7,24 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
 )
 
 var (
-	file         = flag.String("file", "", "file to update in place")
+	file         = flag.String("file", "", "file to update in place (if not provided, pandoctor runs as a Pandoc JSON filter)")
 	ignoreErrors = flag.Bool("ignore_errors", false, "set to leave a table as-is if there is an error")
 )
 
+// actions are the names of the actions. Each of them needs --file.
+var actions = []string{
+	"convert_tables", "csv_table", "diff_tables", "drop_column", "expand_tables", "extract_tables", "fmt_tables",
+	"insert_column", "list_tables", "merge_tables", "rename_column", "reorder_columns", "repair_tables", "resize_tables",
+	"sort_tables", "transpose_tables",
+}
+
 func main() {
 	flag.Parse()
 	if err := mainErr(); err != nil {
@@ -24,11 +34,27 @@ func main() {
 }
 
 func mainErr() error {
+	if *file == "" {
+		// Pandoc runs filters with the output format as the only argument, so there is no action.
+		if flag.NArg() != 0 && slices.Contains(actions, strings.ToLower(flag.Arg(0))) {
+			return fmt.Errorf("--file must be provided for %v", strings.ToLower(flag.Arg(0)))
+		}
+		return filterMain()
+	}
 	args := flag.Args()
 	if len(args) < 1 {
 		return errors.New("please provide an action")
 	}
 
+	action := strings.ToLower(args[0])
+	switch action {
+	case "csv_table", "diff_tables", "extract_tables", "list_tables":
+		// These actions read --file without updating it.
+		return readOnlyMain(action)
+	case "merge_tables":
+		return mergeMain()
+	}
+
 	f, err := os.OpenFile(*file, os.O_RDWR, 0)
 	if err != nil {
 		return err
@@ -40,8 +66,20 @@ func mainErr() error {
 		return err
 	}
 
+	if err := applyConfig(); err != nil {
+		return err
+	}
+	if err := applyDocumentConfig(contents); err != nil {
+		return err
+	}
+	if err := validateReportArgs(); err != nil {
+		return err
+	}
+	if err := loadChangedLines(); err != nil {
+		return err
+	}
+
 	var newContents []byte
-	action := strings.ToLower(args[0])
 	switch action {
 	case "convert_tables":
 		// TODO: reorganize the CLI commands so that these checks happen in a sensible place.
@@ -54,6 +92,18 @@ func mainErr() error {
 			return err
 		}
 		newContents, err = resizeTables(contents)
+	case "fmt_tables":
+		newContents, err = fmtTables(contents)
+	case "repair_tables":
+		newContents, err = repairTables(contents)
+	case "sort_tables":
+		newContents, err = sortTables(contents)
+	case "expand_tables":
+		newContents, err = expandTables(contents)
+	case "transpose_tables":
+		newContents, err = transposeTables(contents)
+	case "drop_column", "insert_column", "rename_column", "reorder_columns":
+		newContents, err = editColumns(contents, action)
 	default:
 		return fmt.Errorf("unknown action: %q", action)
 	}
@@ -70,5 +120,80 @@ func mainErr() error {
 	if _, err := f.Write(newContents); err != nil {
 		return err
 	}
-	return nil
+	return writeReport(os.Stdout)
+}
+
+// readOnlyMain runs an action that reads --file and writes its results elsewhere.
+func readOnlyMain(action string) error {
+	if err := applyConfig(); err != nil {
+		return err
+	}
+	if *reportFormat != "" {
+		return fmt.Errorf("--report is not supported by %v", action)
+	}
+	switch action {
+	case "csv_table":
+		// csv_table reads --file as CSV and prints it as a table.
+		return printCSVTable(os.Stdout)
+	case "list_tables":
+		// list_tables also lists the tables in any files given after the action.
+		return listTables(os.Stdout, append([]string{*file}, flag.Args()[1:]...))
+	case "diff_tables":
+		// diff_tables compares --file with the old version of it given after the action.
+		if flag.NArg() != 2 {
+			return errors.New("diff_tables needs the old version of the file after the action")
+		}
+		return diffTables(os.Stdout, flag.Arg(1))
+	}
+	contents, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	return extractTables(contents)
+}
+
+// filterMain runs pandoctor as a Pandoc JSON filter on stdin and stdout.
+func filterMain() error {
+	if err := applyConfig(); err != nil {
+		return err
+	}
+	if *reportFormat != "" {
+		return errors.New("--report is not supported when running as a Pandoc filter")
+	}
+	if err := validateConvertTablesArgs(); err != nil {
+		return err
+	}
+	if m, err := tableMatcherFromFlags(); err != nil {
+		return err
+	} else if m != nil || *newWidths != "" || len(resizeRules) != 0 {
+		if err := validateResizeTablesArgs(); err != nil {
+			return err
+		}
+	}
+	format := ""
+	if flag.NArg() != 0 {
+		format = flag.Arg(0)
+	}
+	return runFilter(os.Stdin, os.Stdout, format)
+}
+
+// reportTableError prints an error about the table starting at contents[tableStart] to stderr, in the usual
+// file:line:column form. If the error has a position, the offending line is printed too.
+func reportTableError(contents []byte, tableStart int, err error) {
+	reportTableErrorIn(*file, contents, tableStart, err)
+}
+
+// reportTableErrorIn is like reportTableError, for a table in the file at path.
+func reportTableErrorIn(path string, contents []byte, tableStart int, err error) {
+	var tableErr *gridtable.TableError
+	if !errors.As(err, &tableErr) || tableErr.Line == 0 {
+		fmt.Fprintf(os.Stderr, "%v:%d: %v\n", path, lineNumber(contents, tableStart), err)
+		return
+	}
+	if tableErr.Column == 0 {
+		fmt.Fprintf(os.Stderr, "%v:%d: %v\n", path, tableErr.Line, tableErr.Err)
+	} else {
+		fmt.Fprintf(os.Stderr, "%v:%d:%d: %v\n", path, tableErr.Line, tableErr.Column, tableErr.Err)
+	}
+	fmt.Fprintf(os.Stderr, "%v\n", tableErr.Snippet())
 }
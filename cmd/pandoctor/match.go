@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	matchColumns     = flag.String("match_columns", "", "column headings to match (comma-separated, case-insensitive, MD formatting stripped; prefix a heading with re: or glob: to match a pattern against the heading as written)")
+	matchPrefix      = flag.Bool("match_prefix", false, "set to match --match_columns against only the first columns of the table")
+	matchID          = flag.String("match_id", "", "table ID to match (from the table's caption)")
+	matchCaption     = flag.String("match_caption", "", "table caption to match (case-insensitive; prefix with re: or glob: to match a pattern)")
+	matchColumnCount = flag.Int("match_column_count", 0, "number of columns to match")
+)
+
+// A tableMatcher selects tables by their headings, caption, ID or number of columns.
+// All of the criteria that are set must match.
+type tableMatcher struct {
+	// Patterns to match against the headings in the first row of the table.
+	Columns []string `yaml:"columns"`
+	// If set, Columns only needs to match the first len(Columns) headings of the table.
+	Prefix bool `yaml:"prefix"`
+	// Pattern to match against the ID of the table.
+	ID string `yaml:"id"`
+	// Pattern to match against the caption of the table.
+	Caption string `yaml:"caption"`
+	// Number of columns the table has.
+	ColumnCount int `yaml:"column_count"`
+
+	columnPatterns []*textPattern
+	idPattern      *textPattern
+	captionPattern *textPattern
+}
+
+// tableMatcherFromFlags returns the tableMatcher described by the --match_* flags, or nil if none of them were set.
+func tableMatcherFromFlags() (*tableMatcher, error) {
+	m := tableMatcher{
+		Prefix:      *matchPrefix,
+		ID:          *matchID,
+		Caption:     *matchCaption,
+		ColumnCount: *matchColumnCount,
+	}
+	if len(*matchColumns) != 0 {
+		m.Columns = strings.Split(*matchColumns, ",")
+	}
+	if m.empty() {
+		if m.Prefix {
+			return nil, fmt.Errorf("--match_prefix requires --match_columns")
+		}
+		return nil, nil
+	}
+	if err := m.compile(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// empty returns whether no criteria are set on the matcher.
+func (m *tableMatcher) empty() bool {
+	return len(m.Columns) == 0 && m.ID == "" && m.Caption == "" && m.ColumnCount == 0
+}
+
+// compile validates and compiles the patterns in the matcher.
+func (m *tableMatcher) compile() error {
+	if m.empty() {
+		return fmt.Errorf("at least one of columns, id, caption or column_count must be provided")
+	}
+	if m.ColumnCount < 0 {
+		return fmt.Errorf("column_count cannot be negative")
+	}
+	if m.ColumnCount != 0 && len(m.Columns) > m.ColumnCount {
+		return fmt.Errorf("%v columns can never match a table with %v columns", len(m.Columns), m.ColumnCount)
+	}
+	m.columnPatterns = nil
+	for _, col := range m.Columns {
+		p, err := compileTextPattern(col)
+		if err != nil {
+			return err
+		}
+		m.columnPatterns = append(m.columnPatterns, p)
+	}
+	var err error
+	if m.ID != "" {
+		if m.idPattern, err = compileTextPattern(m.ID); err != nil {
+			return err
+		}
+	}
+	if m.Caption != "" {
+		if m.captionPattern, err = compileTextPattern(m.Caption); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns whether the table block matches.
+func (m *tableMatcher) match(block *gridtable.Block) bool {
+	if m.ColumnCount != 0 && len(block.Config.Columns) != m.ColumnCount {
+		return false
+	}
+	caption := block.Caption
+	if m.idPattern != nil && (caption == nil || !m.idPattern.match(caption.Attributes.ID)) {
+		return false
+	}
+	if m.captionPattern != nil && (caption == nil || !m.captionPattern.match(caption.Text)) {
+		return false
+	}
+	if len(block.Rows) == 0 {
+		return false
+	}
+	firstRow := block.Rows[0]
+	if len(m.columnPatterns) == 0 {
+		return true
+	}
+	if len(firstRow) < len(m.columnPatterns) || (!m.Prefix && len(firstRow) != len(m.columnPatterns)) {
+		return false
+	}
+	for i, p := range m.columnPatterns {
+		// Current version doesn't support matching tables with spans in the header.
+		if firstRow[i] == nil || firstRow[i].ColSpan != 0 {
+			return false
+		}
+		if !p.match(firstRow[i].Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// A textPattern matches text. By default, it matches exactly (case-insensitive, MD formatting stripped).
+// With a "re:" prefix, the rest of the pattern is a regular expression, matched against the text as it is written.
+// With a "glob:" prefix, the rest of the pattern is a (case-insensitive) glob where '*' matches any run of characters
+// and '?' matches any single character, also matched against the text as it is written.
+type textPattern struct {
+	exact string
+	re    *regexp.Regexp
+}
+
+func compileTextPattern(pattern string) (*textPattern, error) {
+	switch {
+	case strings.HasPrefix(pattern, "re:"):
+		re, err := regexp.Compile(strings.TrimPrefix(pattern, "re:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		return &textPattern{re: re}, nil
+	case strings.HasPrefix(pattern, "glob:"):
+		glob := regexp.QuoteMeta(strings.TrimPrefix(pattern, "glob:"))
+		glob = strings.ReplaceAll(glob, `\*`, `.*`)
+		glob = strings.ReplaceAll(glob, `\?`, `.`)
+		return &textPattern{re: regexp.MustCompile("(?i)^" + glob + "$")}, nil
+	default:
+		return &textPattern{exact: stripFormatting(pattern)}, nil
+	}
+}
+
+func (p *textPattern) match(text string) bool {
+	if p.re != nil {
+		return p.re.MatchString(strings.TrimSpace(text))
+	}
+	return strings.EqualFold(stripFormatting(text), p.exact)
+}
+
+// stripFormatting trims Markdown formatting from text. Like Pandoc, it leaves underscores within words alone, e.g. in
+// "REG_A".
+func stripFormatting(text string) string {
+	text = strings.ReplaceAll(text, "*", "")
+	text = strings.ReplaceAll(text, "`", "")
+	runes := []rune(text)
+	var sb strings.Builder
+	for i := 0; i < len(runes); {
+		if runes[i] != '_' {
+			sb.WriteRune(runes[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(runes) && runes[end] == '_' {
+			end++
+		}
+		if i > 0 && isWordRune(runes[i-1]) && end < len(runes) && isWordRune(runes[end]) {
+			sb.WriteString(string(runes[i:end]))
+		}
+		i = end
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// isWordRune returns whether r can be part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCompileTextPattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		// Literal patterns match the whole text, case-insensitively and ignoring formatting.
+		{"Name", "Name", true},
+		{"Name", "name", true},
+		{"Name", "**Name**", true},
+		{"Name", "  `Name`  ", true},
+		{"Name", "Names", false},
+		{"Name", "Full name", false},
+		// Literal patterns don't treat regular expression syntax specially.
+		{"a.b", "a.b", true},
+		{"a.b", "axb", false},
+		{"(x)", "(x)", true},
+		// Regular expressions aren't anchored unless they say so.
+		{"re:name", "Full name", true},
+		{"re:^name$", "Full name", false},
+		{"re:^Full", "Full name", true},
+		{"re:^Full", "The Full name", false},
+		{"re:(?i)^NAME$", "name", true},
+		{"re:^name$", "Name", false},
+		// Underscores within words aren't formatting.
+		{"REG_A", "REG_A", true},
+		{"REG_A", "`REG_A`", true},
+		{"REG_A", "_REG_A_", true},
+		{"REGA", "REG_A", false},
+		{"Name", "__Name__", true},
+		// Patterns see the text as it is written.
+		{"re:^REG_", "REG_A", true},
+		{"re:^REGA$", "REG_A", false},
+		{"re:^\\*\\*Name\\*\\*$", "**Name**", true},
+		{"re:^Name$", "  Name  ", true},
+		{"glob:reg_*", "REG_A", true},
+		{"glob:rega", "REG_A", false},
+		// Globs match the whole text, case-insensitively.
+		{"glob:type*", "Type (bits)", true},
+		{"glob:type*", "Data type", false},
+		{"glob:reg?", "Reg0", true},
+		{"glob:reg?", "Reg10", false},
+		{"glob:a.b", "axb", false},
+	} {
+		t.Run(tc.pattern+"/"+tc.text, func(t *testing.T) {
+			p, err := compileTextPattern(tc.pattern)
+			if err != nil {
+				t.Fatalf("compileTextPattern(%q) = %v", tc.pattern, err)
+			}
+			if got := p.match(tc.text); got != tc.want {
+				t.Errorf("match(%q) = %v, want %v", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompileInvalidTextPattern(t *testing.T) {
+	for _, pattern := range []string{"re:(", "re:[a-", "re:*"} {
+		t.Run(pattern, func(t *testing.T) {
+			if p, err := compileTextPattern(pattern); err == nil {
+				t.Errorf("compileTextPattern(%q) = %+v, want error", pattern, p)
+			}
+		})
+	}
+}
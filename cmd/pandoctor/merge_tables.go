@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/chrisfenner/pandoctor/pkg/tablediff"
+)
+
+// A mergeVersion is one of the three versions of a document being merged by merge_tables.
+type mergeVersion struct {
+	// The lines of the document, with each grid table replaced by a token line that identifies it.
+	lines []string
+	// The grid tables in the document, by their tokens.
+	tables map[string]*mergeTable
+}
+
+// A mergeTable is a grid table in a version of a document being merged.
+type mergeTable struct {
+	text  string
+	table *gridtable.Table
+	cells [][]string
+}
+
+// mergeToken returns the line that stands for the table with the given key in a version of a document. Tables that
+// were changed from the base version are marked as such, so that the line-based merge notices the change.
+func mergeToken(key string, changed bool) string {
+	if changed {
+		key += "*"
+	}
+	return "\x00" + key + "\n"
+}
+
+// mergeKey returns the key of the table that a line stands for, or "" if it isn't a token.
+func mergeKey(line string) string {
+	if !strings.HasPrefix(line, "\x00") {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line[1:], "\n"), "*")
+}
+
+// readMergeTables reads the grid tables in a version of a document. Tables that can't be read are merged as text.
+func readMergeTables(contents []byte) ([]*docTable, []tableBlock, []*mergeTable) {
+	var tables []*docTable
+	var locs []tableBlock
+	var merged []*mergeTable
+	for n, loc := range findGridTables(contents) {
+		t, err := readDocTable(loc.text(contents))
+		if err != nil {
+			continue
+		}
+		t.n, t.line = n+1, lineNumber(contents, loc.tableStart)
+		tables = append(tables, t)
+		locs = append(locs, loc)
+		merged = append(merged, &mergeTable{
+			text:  string(contents[loc.tableStart:loc.tableEnd]),
+			table: t.table,
+			cells: tablediff.Text(t.table),
+		})
+	}
+	return tables, locs, merged
+}
+
+// newMergeVersion replaces the tables in a version of a document with tokens, using the given key for each table.
+func newMergeVersion(contents []byte, locs []tableBlock, tables []*mergeTable, keys []string, base *mergeVersion) *mergeVersion {
+	result := mergeVersion{tables: make(map[string]*mergeTable)}
+	var sb strings.Builder
+	last := 0
+	for n, loc := range locs {
+		sb.Write(contents[last:loc.tableStart])
+		changed := false
+		if base != nil {
+			if baseTable, ok := base.tables[keys[n]]; ok {
+				changed = !sameCells(baseTable.cells, tables[n].cells)
+			}
+		}
+		sb.WriteString(mergeToken(keys[n], changed))
+		result.tables[keys[n]] = tables[n]
+		last = loc.tableEnd
+	}
+	sb.Write(contents[last:])
+	result.lines = strings.SplitAfter(sb.String(), "\n")
+	if result.lines[len(result.lines)-1] == "" {
+		result.lines = result.lines[:len(result.lines)-1]
+	}
+	return &result
+}
+
+// sameCells returns whether two versions of a table have the same text in each cell.
+func sameCells(a, b [][]string) bool {
+	return slices.EqualFunc(a, b, slices.Equal[[]string])
+}
+
+// mergeDocuments merges the changes made to base in ours and in theirs. The text outside the grid tables is merged
+// line by line, and each grid table changed in both versions is merged cell by cell. Changes that conflict are
+// marked with conflict markers. mergeDocuments returns the merged document and the number of conflicts.
+func mergeDocuments(base, ours, theirs []byte) ([]byte, int) {
+	baseTables, baseLocs, baseMerge := readMergeTables(base)
+	oursTables, oursLocs, oursMerge := readMergeTables(ours)
+	theirsTables, theirsLocs, theirsMerge := readMergeTables(theirs)
+
+	// Pair the tables in each version with the tables in the base version.
+	keyOf := make(map[*docTable]string)
+	for _, t := range baseTables {
+		keyOf[t] = fmt.Sprintf("b%d", t.n)
+	}
+	for prefix, tables := range map[string][]*docTable{"o": oursTables, "t": theirsTables} {
+		pairs, _, added := pairDocTables(baseTables, tables)
+		for _, pair := range pairs {
+			keyOf[pair[1]] = keyOf[pair[0]]
+		}
+		for _, t := range added {
+			keyOf[t] = fmt.Sprintf("%v%d", prefix, t.n)
+		}
+	}
+	keys := func(tables []*docTable) []string {
+		var result []string
+		for _, t := range tables {
+			result = append(result, keyOf[t])
+		}
+		return result
+	}
+	b := newMergeVersion(base, baseLocs, baseMerge, keys(baseTables), nil)
+	o := newMergeVersion(ours, oursLocs, oursMerge, keys(oursTables), b)
+	t := newMergeVersion(theirs, theirsLocs, theirsMerge, keys(theirsTables), b)
+
+	var sb strings.Builder
+	conflicts := 0
+	write := func(lines []string, table func(key string) string) {
+		for _, line := range lines {
+			if key := mergeKey(line); key != "" {
+				line = table(key)
+			}
+			sb.WriteString(line)
+		}
+	}
+	merged := func(key string) string {
+		text, n := mergeTableVersions(b.tables[key], o.tables[key], t.tables[key])
+		conflicts += n
+		return text
+	}
+	for _, c := range tablediff.Diff3(b.lines, o.lines, t.lines) {
+		bl, ol, tl := b.lines[c.Base[0]:c.Base[1]], o.lines[c.Ours[0]:c.Ours[1]], t.lines[c.Theirs[0]:c.Theirs[1]]
+		switch {
+		case c.Stable || slices.Equal(tl, bl) || slices.Equal(ol, tl):
+			write(ol, merged)
+		case slices.Equal(ol, bl):
+			write(tl, merged)
+		default:
+			conflicts++
+			writeConflict(&sb,
+				func() { write(ol, func(key string) string { return o.tables[key].text }) },
+				func() { write(tl, func(key string) string { return t.tables[key].text }) })
+		}
+	}
+	return []byte(sb.String()), conflicts
+}
+
+// writeConflict writes ours and theirs between conflict markers.
+func writeConflict(sb *strings.Builder, ours, theirs func()) {
+	endLine := func() {
+		if s := sb.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	endLine()
+	sb.WriteString(tablediff.OursMarker + "\n")
+	ours()
+	endLine()
+	sb.WriteString(tablediff.Separator + "\n")
+	theirs()
+	endLine()
+	sb.WriteString(tablediff.TheirsMarker + "\n")
+}
+
+// mergeTableVersions merges the versions of a table, any of which may be missing, and returns the merged text and the
+// number of conflicts. A table changed in only one version is kept exactly as it is in that version.
+func mergeTableVersions(base, ours, theirs *mergeTable) (string, int) {
+	switch {
+	case base == nil || ours == nil || theirs == nil:
+		// The table was added (or deleted) in one version, and the merged lines chose that version.
+		for _, t := range []*mergeTable{ours, theirs, base} {
+			if t != nil {
+				return t.text, 0
+			}
+		}
+		return "", 0
+	case sameCells(theirs.cells, base.cells) || sameCells(ours.cells, theirs.cells):
+		return ours.text, 0
+	case sameCells(ours.cells, base.cells):
+		return theirs.text, 0
+	}
+	table, conflicts, err := tablediff.Merge(base.table, ours.table, theirs.table)
+	if err == nil {
+		var text string
+		if text, err = renderMergedTable(table); err == nil {
+			// Keep the table where it was, e.g. in a block quote.
+			return gridtable.AddPrefix(text, gridtable.LinePrefix(ours.text)), conflicts
+		}
+	}
+	// The table can't be merged cell by cell, so it conflicts as a whole.
+	var sb strings.Builder
+	writeConflict(&sb, func() { sb.WriteString(ours.text) }, func() { sb.WriteString(theirs.text) })
+	return sb.String(), 1
+}
+
+// renderMergedTable renders a merged table. If the conflict markers in its cells don't fit in the columns, the
+// columns are widened.
+func renderMergedTable(table *gridtable.Table) (string, error) {
+	text, err := table.String()
+	if errors.Is(err, gridtable.ErrBadWrap) {
+		width := (&gridtable.Block{Config: table.Config()}).Width()
+		table.FitColumns(max(width, *tableWidth))
+		text, err = table.String()
+	}
+	return text, err
+}
+
+// mergeMain runs merge_tables as a git merge driver: it merges the changes made to the base version of --file (given
+// after the action) in --file and in the other version (given after the base version), and updates --file with the
+// result. It fails if there are any conflicts, which are marked in --file, so that git reports the conflict: the
+// markers of a conflict within a cell don't start a line, so they can't be found by looking for them.
+func mergeMain() error {
+	if err := applyConfig(); err != nil {
+		return err
+	}
+	if *reportFormat != "" {
+		return errors.New("--report is not supported by merge_tables")
+	}
+	if flag.NArg() != 3 {
+		return errors.New("merge_tables needs the base and other versions of the file after the action")
+	}
+	base, err := os.ReadFile(flag.Arg(1))
+	if err != nil {
+		return err
+	}
+	theirs, err := os.ReadFile(flag.Arg(2))
+	if err != nil {
+		return err
+	}
+	// Update --file in place, so that it keeps its mode.
+	f, err := os.OpenFile(*file, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ours, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	merged, conflicts := mergeDocuments(base, ours, theirs)
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+	if _, err := f.Write(merged); err != nil {
+		return err
+	}
+	if conflicts != 0 {
+		return fmt.Errorf("%v: %d conflicts", *file, conflicts)
+	}
+	return nil
+}
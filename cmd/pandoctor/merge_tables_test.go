@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// TestMergeDriver runs the test binary as pandoctor.
+	if os.Getenv("PANDOCTOR_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+const mergeBase = `# Registers
+
++--------+--------+
+| Offset | Name   |
++========+========+
+| 0x00   | CTRL   |
++--------+--------+
+| 0x04   | STATUS |
++--------+--------+
+`
+
+// setArgs sets the arguments after the flags for the duration of the test.
+func setArgs(t *testing.T, args ...string) {
+	t.Helper()
+	old := flag.Args()
+	if err := flag.CommandLine.Parse(args); err != nil {
+		t.Fatalf("Parse(%q) = %v", args, err)
+	}
+	t.Cleanup(func() { flag.CommandLine.Parse(old) })
+}
+
+func TestMergeMainKeepsMode(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.md":   mergeBase,
+		"ours.md":   strings.Replace(mergeBase, "CTRL  ", "CONFIG", 1),
+		"theirs.md": strings.Replace(mergeBase, "STATUS", "STAT  ", 1),
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ours := filepath.Join(dir, "ours.md")
+	if err := os.Chmod(ours, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, "file", ours)
+	setArgs(t, "merge_tables", filepath.Join(dir, "base.md"), filepath.Join(dir, "theirs.md"))
+	if err := mergeMain(); err != nil {
+		t.Fatalf("mergeMain() = %v", err)
+	}
+	got, err := os.ReadFile(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "CONFIG") || !strings.Contains(string(got), "STAT ") {
+		t.Errorf("mergeMain() wrote\n%v\nwant both changes", string(got))
+	}
+	info, err := os.Stat(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("mode = %v, want %v", info.Mode().Perm(), os.FileMode(0o755))
+	}
+}
+
+// TestMergeDriver checks that git reports a conflict within a cell, even though its markers don't start a line.
+func TestMergeDriver(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "PANDOCTOR_TEST_MAIN=1", "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+	mustGit := func(args ...string) {
+		t.Helper()
+		if out, err := git(args...); err != nil {
+			t.Fatalf("git %v = %v\n%v", strings.Join(args, " "), err, out)
+		}
+	}
+	commit := func(contents, message string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mustGit("commit", "-q", "-a", "-m", message)
+	}
+	mustGit("init", "-q", "-b", "main")
+	mustGit("config", "user.name", "Test")
+	mustGit("config", "user.email", "test@example.com")
+	mustGit("config", "merge.pandoctor.driver", exe+" --file %A merge_tables %O %B")
+	if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte("*.md merge=pandoctor\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte(mergeBase), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustGit("add", ".")
+	mustGit("commit", "-q", "-m", "base")
+	mustGit("checkout", "-q", "-b", "theirs")
+	commit(strings.Replace(mergeBase, "CTRL  ", "CONFIG", 1), "theirs")
+	mustGit("checkout", "-q", "main")
+	commit(strings.Replace(mergeBase, "CTRL  ", "CONTRL", 1), "ours")
+
+	out, err := git("merge", "theirs")
+	if err == nil {
+		t.Fatalf("git merge succeeded, want a conflict\n%v", out)
+	}
+	if !strings.Contains(out, "CONFLICT") {
+		t.Errorf("git merge didn't report a conflict:\n%v", out)
+	}
+	unmerged, err := git("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		t.Fatalf("git diff = %v\n%v", err, unmerged)
+	}
+	if strings.TrimSpace(unmerged) != "doc.md" {
+		t.Errorf("unmerged files = %q, want doc.md", unmerged)
+	}
+	merged, err := os.ReadFile(filepath.Join(dir, "doc.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| <<<<<<< ours", "| CONTRL", "| CONFIG", "| >>>>>>> theirs"} {
+		if !strings.Contains(string(merged), want) {
+			t.Errorf("merged document has no %q:\n%v", want, string(merged))
+		}
+	}
+}
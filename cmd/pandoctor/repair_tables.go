@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+// repairTables rewrites the slightly malformed grid tables in the document (see gridtable.NewLenientReader), and lists
+// what was fixed in each one.
+func repairTables(contents []byte) ([]byte, error) {
+	return processTables(contents, "repair_tables", findTables(contents, lenientGridTableRe), repairGridTable), nil
+}
+
+func repairGridTable(contents []byte, loc tableBlock) ([]byte, string, error) {
+	block, repairs, err := gridtable.ReadLenientBlock(string(loc.text(contents)))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(repairs) == 0 {
+		return loc.text(contents), statusUnchanged, nil
+	}
+	result, status, err := renderBlock(contents, loc, block)
+	if err != nil {
+		return nil, "", err
+	}
+	// Don't get in the way of the report.
+	if *reportFormat == "" {
+		firstLine := lineNumber(contents, loc.tableStart)
+		for _, repair := range repairs {
+			fmt.Fprintf(os.Stdout, "%v:%d: %v\n", *file, firstLine+repair.Line-1, repair.Description)
+		}
+	}
+	return result, status, nil
+}
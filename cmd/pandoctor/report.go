@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	reportFormat = flag.String("report", "", "set to json or sarif to write a diagnostic for every table processed to stdout")
+)
+
+// Statuses of a table in a report.
+const (
+	statusChanged   = "changed"
+	statusUnchanged = "unchanged"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+)
+
+// A tableDiagnostic describes what happened to one table.
+type tableDiagnostic struct {
+	File string `json:"file"`
+	// The (1-based, inclusive) range of lines the table occupied in the original file.
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Action    string `json:"action"`
+	Status    string `json:"status"`
+	// For failed tables: the kind of error (see errorKinds), a description, and the position of the error if known.
+	ErrorKind   string `json:"error_kind,omitempty"`
+	Message     string `json:"message,omitempty"`
+	ErrorLine   int    `json:"error_line,omitempty"`
+	ErrorColumn int    `json:"error_column,omitempty"`
+}
+
+// diagnostics are the diagnostics recorded so far.
+var diagnostics []tableDiagnostic
+
+// errorKinds maps the gridtable sentinel errors to the error kinds used in reports.
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{gridtable.ErrColumnIndexOutOfRange, "column_index_out_of_range"},
+	{gridtable.ErrShadowedCell, "shadowed_cell"},
+	{gridtable.ErrNegativeSpan, "negative_span"},
+	{gridtable.ErrOverlappingSpans, "overlapping_spans"},
+	{gridtable.ErrSpanBeyondHeader, "span_beyond_header"},
+	{gridtable.ErrInvalidColumnSpec, "invalid_column_spec"},
+	{gridtable.ErrBadWrap, "bad_wrap"},
+	{gridtable.ErrMalformedTable, "malformed_table"},
+	{gridtable.ErrReaderNotDone, "reader_not_done"},
+}
+
+// errorKind returns the kind of the error for reports.
+func errorKind(err error) string {
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.err) {
+			return kind.kind
+		}
+	}
+	return "other"
+}
+
+func validateReportArgs() error {
+	switch *reportFormat {
+	case "", "json", "sarif":
+		return nil
+	}
+	return fmt.Errorf("--report must be json or sarif, not %q", *reportFormat)
+}
+
+// recordTable records a diagnostic for the table at contents[start:end]. err is the reason the table failed, if it did.
+func recordTable(contents []byte, start int, end int, action string, status string, err error) {
+	diag := tableDiagnostic{
+		File:      *file,
+		StartLine: lineNumber(contents, start),
+		EndLine:   lineNumber(contents, max(start, end-1)),
+		Action:    action,
+		Status:    status,
+	}
+	if err != nil {
+		diag.ErrorKind = errorKind(err)
+		diag.Message = err.Error()
+		var tableErr *gridtable.TableError
+		if errors.As(err, &tableErr) {
+			diag.Message = tableErr.Err.Error()
+			diag.ErrorLine = tableErr.Line
+			diag.ErrorColumn = tableErr.Column
+		}
+	}
+	diagnostics = append(diagnostics, diag)
+}
+
+// writeReport writes the recorded diagnostics in the format requested by --report.
+func writeReport(w io.Writer) error {
+	var report any
+	switch *reportFormat {
+	case "":
+		return nil
+	case "json":
+		report = diagnostics
+		if diagnostics == nil {
+			report = []tableDiagnostic{}
+		}
+	case "sarif":
+		report = sarifReport(diagnostics)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// The subset of SARIF 2.1.0 that pandoctor uses.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+}
+
+// sarifReport converts diagnostics to a SARIF log. Failed tables are errors, changed tables are notes, and other
+// tables are included with level "none".
+func sarifReport(diags []tableDiagnostic) *sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "pandoctor",
+				InformationURI: "https://github.com/chrisfenner/pandoctor",
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+	seenRules := make(map[string]bool)
+	for _, diag := range diags {
+		result := sarifResult{
+			RuleID: diag.Status,
+			Level:  "none",
+			Message: sarifMessage{
+				Text: fmt.Sprintf("%v: table %v", diag.Action, diag.Status),
+			},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: diag.File},
+					Region: sarifRegion{
+						StartLine: diag.StartLine,
+						EndLine:   diag.EndLine,
+					},
+				},
+			}},
+			Properties: map[string]string{
+				"action": diag.Action,
+				"status": diag.Status,
+			},
+		}
+		switch diag.Status {
+		case statusFailed:
+			result.RuleID = diag.ErrorKind
+			result.Level = "error"
+			result.Message.Text = fmt.Sprintf("%v: %v", diag.Action, diag.Message)
+			if diag.ErrorLine != 0 {
+				result.Locations[0].PhysicalLocation.Region = sarifRegion{
+					StartLine:   diag.ErrorLine,
+					StartColumn: diag.ErrorColumn,
+				}
+			}
+		case statusChanged:
+			result.Level = "note"
+		}
+		if !seenRules[result.RuleID] {
+			seenRules[result.RuleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: result.RuleID})
+		}
+		run.Results = append(run.Results, result)
+	}
+	return &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
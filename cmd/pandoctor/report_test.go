@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/google/go-cmp/cmp"
+)
+
+const reportDoc = `# Registers
+
++------+------+
+| A    | B    |
++======+======+
+| 1    | 2    |
++------+------+
+
++------+------+
+|A     |   B  |
++======+======+
+| 1    | 2    |
++------+------+
+
+<!-- pandoctor: skip -->
+
++------+------+
+|A     |   B  |
++------+------+
+
++------+------+
+| A    | B    |
++======+======+
+| 1    |
++------+------+
+`
+
+// reportFor runs fmt_tables on reportDoc and returns the report in the given format.
+func reportFor(t *testing.T, format string) string {
+	t.Helper()
+	setFlag(t, "file", "doc.md")
+	setFlag(t, "report", format)
+	oldDiagnostics := diagnostics
+	t.Cleanup(func() { diagnostics = oldDiagnostics })
+	diagnostics = nil
+	if _, err := fmtTables([]byte(reportDoc)); err != nil {
+		t.Fatalf("fmtTables() = %v", err)
+	}
+	var got bytes.Buffer
+	if err := writeReport(&got); err != nil {
+		t.Fatalf("writeReport() = %v", err)
+	}
+	return got.String()
+}
+
+func TestJSONReport(t *testing.T) {
+	want := `[
+  {
+    "file": "doc.md",
+    "start_line": 3,
+    "end_line": 7,
+    "action": "fmt_tables",
+    "status": "unchanged"
+  },
+  {
+    "file": "doc.md",
+    "start_line": 9,
+    "end_line": 13,
+    "action": "fmt_tables",
+    "status": "changed"
+  },
+  {
+    "file": "doc.md",
+    "start_line": 17,
+    "end_line": 19,
+    "action": "fmt_tables",
+    "status": "skipped"
+  },
+  {
+    "file": "doc.md",
+    "start_line": 21,
+    "end_line": 25,
+    "action": "fmt_tables",
+    "status": "failed",
+    "error_kind": "malformed_table",
+    "message": "malformed grid table: each line of text needs to have the same width (expected 15 characters, got 8)",
+    "error_line": 24,
+    "error_column": 9
+  }
+]
+`
+	if diff := cmp.Diff(want, reportFor(t, "json")); diff != "" {
+		t.Errorf("writeReport() diff (-want +got)\n%v", diff)
+	}
+}
+
+func TestSARIFReport(t *testing.T) {
+	want := `{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "pandoctor",
+          "informationUri": "https://github.com/chrisfenner/pandoctor",
+          "rules": [
+            {
+              "id": "unchanged"
+            },
+            {
+              "id": "changed"
+            },
+            {
+              "id": "skipped"
+            },
+            {
+              "id": "malformed_table"
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "unchanged",
+          "level": "none",
+          "message": {
+            "text": "fmt_tables: table unchanged"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "doc.md"
+                },
+                "region": {
+                  "startLine": 3,
+                  "endLine": 7
+                }
+              }
+            }
+          ],
+          "properties": {
+            "action": "fmt_tables",
+            "status": "unchanged"
+          }
+        },
+        {
+          "ruleId": "changed",
+          "level": "note",
+          "message": {
+            "text": "fmt_tables: table changed"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "doc.md"
+                },
+                "region": {
+                  "startLine": 9,
+                  "endLine": 13
+                }
+              }
+            }
+          ],
+          "properties": {
+            "action": "fmt_tables",
+            "status": "changed"
+          }
+        },
+        {
+          "ruleId": "skipped",
+          "level": "none",
+          "message": {
+            "text": "fmt_tables: table skipped"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "doc.md"
+                },
+                "region": {
+                  "startLine": 17,
+                  "endLine": 19
+                }
+              }
+            }
+          ],
+          "properties": {
+            "action": "fmt_tables",
+            "status": "skipped"
+          }
+        },
+        {
+          "ruleId": "malformed_table",
+          "level": "error",
+          "message": {
+            "text": "fmt_tables: malformed grid table: each line of text needs to have the same width (expected 15 characters, got 8)"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "doc.md"
+                },
+                "region": {
+                  "startLine": 24,
+                  "startColumn": 9
+                }
+              }
+            }
+          ],
+          "properties": {
+            "action": "fmt_tables",
+            "status": "failed"
+          }
+        }
+      ]
+    }
+  ]
+}
+`
+	if diff := cmp.Diff(want, reportFor(t, "sarif")); diff != "" {
+		t.Errorf("writeReport() diff (-want +got)\n%v", diff)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	for _, kind := range errorKinds {
+		err := fmt.Errorf("could not read the table: %w", &gridtable.TableError{Err: fmt.Errorf("%w: details", kind.err), Line: 1})
+		if got := errorKind(err); got != kind.kind {
+			t.Errorf("errorKind(%v) = %q, want %q", err, got, kind.kind)
+		}
+	}
+	if got := errorKind(errors.New("something else")); got != "other" {
+		t.Errorf("errorKind() = %q, want %q", got, "other")
+	}
+}
@@ -1,131 +1,158 @@
 package main
 
 import (
-	"bytes"
+	"errors"
 	"flag"
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
+	"slices"
 
 	"github.com/chrisfenner/pandoctor/pkg/gridtable"
 )
 
 var (
-	matchColumns = flag.String("match_columns", "", "column headings to match (comma-separated, case-insensitive, MD formatting stripped)")
-	newWidths    = flag.String("new_widths", "", "new widths for the column headings (comma-separated, base-10 integers)")
+	newWidths = flag.String("new_widths", "", "new widths for the columns of the matched tables (comma-separated; each a number of characters, a percentage of --table_width, '*' for the remaining space or '=' to keep the current width, optionally followed by [min:max])")
 )
 
+// A resizeRule describes a family of tables and the widths their columns should have.
+type resizeRule struct {
+	// Name of the rule, used in error messages.
+	Name         string `yaml:"name"`
+	tableMatcher `yaml:",inline"`
+	// New widths for the columns.
+	Widths []gridtable.WidthSpec `yaml:"widths"`
+	// Total width of the table used to resolve percentages and '*'. 0 = use --table_width.
+	TableWidth int `yaml:"table_width"`
+}
+
+// resizeRules are the rules applied by resize_tables, in order of precedence.
+var resizeRules []resizeRule
+
+// ruleName returns a name for the i'th rule suitable for error messages.
+func ruleName(i int, rule resizeRule) string {
+	if rule.Name != "" {
+		return fmt.Sprintf("%q", rule.Name)
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// validate checks and compiles the rule.
+func (rule *resizeRule) validate() error {
+	if err := rule.compile(); err != nil {
+		return err
+	}
+	if len(rule.Widths) == 0 {
+		return fmt.Errorf("widths must be provided")
+	}
+	if len(rule.Columns) != 0 && !rule.Prefix && len(rule.Columns) != len(rule.Widths) {
+		return fmt.Errorf("columns must have the same number of entries as widths")
+	}
+	if rule.ColumnCount != 0 && rule.ColumnCount != len(rule.Widths) {
+		return fmt.Errorf("column_count must be the same as the number of widths")
+	}
+	if rule.TableWidth < 0 {
+		return fmt.Errorf("table_width cannot be negative")
+	}
+	return nil
+}
+
+// validateResizeTablesArgs checks the resize flags, and adds the rule they describe (if any) to resizeRules. Whether
+// there is anything for resize_tables to do is up to resizeTables.
 func validateResizeTablesArgs() error {
-	matchCols := strings.Split(*matchColumns, ",")
-	newWids := strings.Split(*newWidths, ",")
-	if len(*matchColumns) == 0 {
-		return fmt.Errorf("both --match_columns and --new_widths must be provided")
+	matcher, err := tableMatcherFromFlags()
+	if err != nil {
+		return err
+	}
+	if matcher == nil && len(*newWidths) == 0 {
+		return nil
 	}
-	if len(matchCols) != len(newWids) {
+	if matcher == nil || len(*newWidths) == 0 {
+		return fmt.Errorf("both --match_columns (or another --match_* flag) and --new_widths must be provided")
+	}
+	widths, err := gridtable.ParseWidthSpecs(*newWidths)
+	if err != nil {
+		return fmt.Errorf("--new_widths: %w", err)
+	}
+	rule := resizeRule{
+		Name:         "command line",
+		tableMatcher: *matcher,
+		Widths:       widths,
+	}
+	if len(rule.Columns) != 0 && !rule.Prefix && len(rule.Columns) != len(rule.Widths) {
 		return fmt.Errorf("--match_columns must have the same number of (comma-separated) fields as --new_widths")
 	}
-	for _, wid := range newWids {
-		if _, err := strconv.Atoi(wid); err != nil {
-			return fmt.Errorf("--new_widths must be a comma-separated list of base-10 integers")
-		}
+	if err := rule.validate(); err != nil {
+		return err
 	}
+	// The rule given on the command line takes precedence over any from the configuration file.
+	resizeRules = append([]resizeRule{rule}, resizeRules...)
 	return nil
 }
 
+// resizeTables resizes the tables in the document according to resizeRules (from the command line, the configuration
+// file and the document's front matter) and the options comments before them.
 func resizeTables(contents []byte) ([]byte, error) {
-	tableRe := regexp.MustCompile("\\+[\\-\\+]+\n([|\\+].*\n)*\\+[\\-=\\+]+\n")
-	return tableRe.ReplaceAllFunc(contents, resizeGridTable), nil
+	if len(resizeRules) == 0 && !hasTableOptions(contents) {
+		return nil, errors.New("either --match_columns (or another --match_* flag) and --new_widths, resize_rules in a configuration file or front matter, or a pandoctor: widths=... comment before a table must be provided")
+	}
+	return processTables(contents, "resize_tables", findGridTables(contents), resizeGridTable), nil
 }
 
-func resizeGridTable(contents []byte) []byte {
-	config, cells, err := getTable(contents)
+func resizeGridTable(contents []byte, loc tableBlock) ([]byte, string, error) {
+	block, err := gridtable.ReadBlock(string(loc.text(contents)))
 	if err != nil {
-		if *ignoreErrors {
-			return contents
-		}
-		return []byte(fmt.Sprintf("Could not read table: %v", err))
+		return nil, "", err
 	}
-	// We're not updating this table.
-	if !matchTable(cells[0], config) {
-		return contents
-	}
-	newTable, err := writeTable(*config, cells)
-	if err != nil {
-		if *ignoreErrors {
-			return contents
+	// Apply the first rule that matches this table, if any.
+	var rule *resizeRule
+	for i := range resizeRules {
+		if resizeRules[i].match(block) {
+			rule = &resizeRules[i]
+			break
 		}
-		return []byte(fmt.Sprintf("Could not write table: %v", err))
 	}
-	return []byte(newTable)
-}
-
-func getTable(contents []byte) (*gridtable.Config, [][]*gridtable.Cell, error) {
-	r, err := gridtable.NewReader(bytes.NewReader(contents))
+	// The options comment before the table, if any, overrides the rule.
+	opts, err := tableOptionsAt(contents, loc)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not initialize table reader: %v", err)
+		return nil, "", err
 	}
-	var cells [][]*gridtable.Cell
-	for row, err := range r.Read() {
-		if err != nil {
-			return nil, nil, fmt.Errorf("could not read table row: %v", err)
+	// We're not updating this table.
+	if rule == nil && !opts.changesTable() {
+		return loc.text(contents), statusSkipped, nil
+	}
+	width := *tableWidth
+	if rule != nil {
+		width = rule.width()
+		if opts.widths == nil {
+			if err := rule.apply(&block.Config); err != nil {
+				return nil, "", err
+			}
 		}
-		cells = append(cells, row)
 	}
-	config, err := r.GetConfig()
-	if err != nil {
-		return nil, nil, fmt.Errorf("could not read table config: %v", err)
+	if err := opts.apply(block, width); err != nil {
+		return nil, "", err
 	}
-	return config, cells, nil
+	return renderBlock(contents, loc, block)
 }
 
-func matchTable(firstRow []*gridtable.Cell, config *gridtable.Config) bool {
-	var headings []string
-	for _, cell := range firstRow {
-		// Current version doesn't support resizing tables with spans in the header.
-		if cell == nil {
-			return false
-		}
-		headings = append(headings, cell.Text)
-	}
-	// Compare all the headings to the flag passed in.
-	matchCols := strings.Split(*matchColumns, ",")
-	if len(headings) != len(matchCols) {
-		return false
-	}
-	for i, heading := range headings {
-		// Trim Markdown formatting and compare case-insensitive.
-		heading = strings.ReplaceAll(heading, "*", "")
-		heading = strings.ReplaceAll(heading, "_", "")
-		heading = strings.ReplaceAll(heading, "`", "")
-		if !strings.EqualFold(heading, matchCols[i]) {
-			return false
-		}
-	}
-	// Update the config based on the passed-in widths.
-	for i, width := range strings.Split(*newWidths, ",") {
-		w, err := strconv.Atoi(width)
-		if err != nil {
-			panic("unexpectedly failed to parse width from new_widths")
-		}
-		config.Columns[i].Width = w
+// width returns the total width of the table used to resolve the rule's widths.
+func (rule *resizeRule) width() int {
+	if rule.TableWidth == 0 {
+		return *tableWidth
 	}
-	return true
+	return rule.TableWidth
 }
 
-func writeTable(config gridtable.Config, cells [][]*gridtable.Cell) (string, error) {
-	w, err := gridtable.NewWriter(config)
-	if err != nil {
-		return "", fmt.Errorf("could not initialize table writer: %v", err)
+// apply updates the config based on the rule's widths. If the rule has fewer widths than the table has columns (e.g.,
+// because it only matches the first columns), the other columns share the remaining space, as if their widths were '*'.
+func (rule *resizeRule) apply(config *gridtable.Config) error {
+	widths := slices.Clone(rule.Widths)
+	for len(widths) < len(config.Columns) {
+		widths = append(widths, gridtable.WidthSpec{Kind: gridtable.WidthRemaining})
 	}
-	for _, row := range cells {
-		for i, cell := range row {
-			if cell == nil {
-				continue
-			}
-			w.WriteColumn(i, *cell)
-		}
-		w.NextRow()
+	cols, err := gridtable.ResolveWidths(widths, config.Columns, rule.width())
+	if err != nil {
+		return err
 	}
-	return w.String()
+	config.Columns = cols
+	return nil
 }
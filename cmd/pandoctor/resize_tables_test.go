@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResizeTablesFewerWidths(t *testing.T) {
+	saveRules(t)
+	setFlag(t, "match_columns", "Name")
+	setFlag(t, "match_prefix", "true")
+	setFlag(t, "table_width", "30")
+	for _, tc := range []struct {
+		name      string
+		newWidths string
+		want      string
+	}{
+		{
+			name:      "fewer widths",
+			newWidths: "10",
+			// The other columns share the rest of the table.
+			want: `+----------+--------+--------+
+| Name     | B      | C      |
++==========+========+========+
+| x        | 1      | 2      |
++----------+--------+--------+
+`,
+		},
+		{
+			name:      "too many widths",
+			newWidths: "10,*,*,5",
+			want: `<!-- pandoctor: could not resize: invalid column spec: 4 widths were given for a table with 3 columns -->
+
++------+---+---+
+| Name | B | C |
++======+===+===+
+| x    | 1 | 2 |
++------+---+---+
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resizeRules = nil
+			setFlag(t, "new_widths", tc.newWidths)
+			if err := validateResizeTablesArgs(); err != nil {
+				t.Fatalf("validateResizeTablesArgs() = %v", err)
+			}
+			got, err := resizeTables([]byte(`+------+---+---+
+| Name | B | C |
++======+===+===+
+| x    | 1 | 2 |
++------+---+---+
+`))
+			if err != nil {
+				t.Fatalf("resizeTables() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("resizeTables() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	sortBy = flag.String("sort_by", "", "columns to sort the body rows of the matched tables by for sort_tables (comma-separated, each selecting a column like --column, optionally followed by :text, :natural, :numeric or :hex and/or :desc)")
+)
+
+// sortOrders compare the text of two cells. Text that can't be parsed sorts after text that can.
+var sortOrders = map[string]func(a, b string) int{
+	"text":    compareText,
+	"natural": compareNatural,
+	"numeric": func(a, b string) int { return compareParsed(a, b, parseNumber) },
+	"hex":     func(a, b string) int { return compareParsed(a, b, parseHex) },
+}
+
+// A sortKey is one of the columns to sort by.
+type sortKey struct {
+	column     *columnSelector
+	compare    func(a, b string) int
+	descending bool
+}
+
+// parseSortKey parses a column selector followed by any of the ":<order>" and ":desc" suffixes.
+func parseSortKey(text string) (*sortKey, error) {
+	key := sortKey{compare: compareNatural}
+	order := ""
+	for {
+		i := strings.LastIndexByte(text, ':')
+		if i == -1 {
+			break
+		}
+		suffix := strings.ToLower(text[i+1:])
+		if suffix == "desc" && !key.descending {
+			key.descending = true
+		} else if f, ok := sortOrders[suffix]; ok && order == "" {
+			key.compare = f
+			order = suffix
+		} else {
+			break
+		}
+		text = text[:i]
+	}
+	var err error
+	if key.column, err = parseColumnSelector(text); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func validateSortTablesArgs() ([]*sortKey, *tableMatcher, error) {
+	matcher, err := tableMatcherFromFlags()
+	if err != nil {
+		return nil, nil, err
+	}
+	if matcher == nil {
+		return nil, nil, fmt.Errorf("sort_tables requires --match_columns (or another --match_* flag) to select the tables to sort")
+	}
+	if *sortBy == "" {
+		return nil, nil, fmt.Errorf("sort_tables requires --sort_by")
+	}
+	var keys []*sortKey
+	for _, text := range strings.Split(*sortBy, ",") {
+		key, err := parseSortKey(text)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--sort_by: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, matcher, nil
+}
+
+func sortTables(contents []byte) ([]byte, error) {
+	keys, matcher, err := validateSortTablesArgs()
+	if err != nil {
+		return nil, err
+	}
+	return processTables(contents, "sort_tables", findGridTables(contents), func(contents []byte, loc tableBlock) ([]byte, string, error) {
+		return sortGridTable(contents, loc, matcher, keys)
+	}), nil
+}
+
+func sortGridTable(contents []byte, loc tableBlock, matcher *tableMatcher, keys []*sortKey) ([]byte, string, error) {
+	block, err := gridtable.ReadBlock(string(loc.text(contents)))
+	if err != nil {
+		return nil, "", err
+	}
+	// We're not updating this table.
+	if !matcher.match(block) {
+		return loc.text(contents), statusSkipped, nil
+	}
+	table, err := gridtable.NewTable(block.Config, block.Rows)
+	if err != nil {
+		return nil, "", err
+	}
+	columns := make([]int, len(keys))
+	for k, key := range keys {
+		if columns[k], err = key.column.find(table); err != nil {
+			return nil, "", err
+		}
+	}
+	// Look up the sort keys before sorting, since that moves the rows around.
+	rows := table.Rows()
+	text := func(i, j int) string {
+		// A cell that spans several columns is the key for each of them.
+		oi, oj, err := table.Owner(i, j)
+		if err != nil {
+			return ""
+		}
+		return strings.Join(strings.Fields(stripFormatting(rows[oi][oj].Text)), " ")
+	}
+	table.SortRows(func(a, b int) int {
+		for k, key := range keys {
+			result := key.compare(text(a, columns[k]), text(b, columns[k]))
+			if key.descending {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	})
+	block.Rows = table.Rows()
+	return renderBlock(contents, loc, block)
+}
+
+// compareText compares text case-insensitively.
+func compareText(a, b string) int {
+	if result := strings.Compare(strings.ToLower(a), strings.ToLower(b)); result != 0 {
+		return result
+	}
+	return strings.Compare(a, b)
+}
+
+// compareNatural compares text case-insensitively, except that runs of digits are compared by their numeric value,
+// so that "Item 9" comes before "Item 10".
+func compareNatural(a, b string) int {
+	x, y := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	for len(x) != 0 && len(y) != 0 {
+		if unicode.IsDigit(x[0]) && unicode.IsDigit(y[0]) {
+			dx, dy := digitRun(x), digitRun(y)
+			nx := strings.TrimLeft(string(x[:dx]), "0")
+			ny := strings.TrimLeft(string(y[:dy]), "0")
+			if result := cmp.Or(cmp.Compare(len(nx), len(ny)), strings.Compare(nx, ny)); result != 0 {
+				return result
+			}
+			x, y = x[dx:], y[dy:]
+			continue
+		}
+		if result := cmp.Compare(x[0], y[0]); result != 0 {
+			return result
+		}
+		x, y = x[1:], y[1:]
+	}
+	if result := cmp.Compare(len(x), len(y)); result != 0 {
+		return result
+	}
+	return strings.Compare(a, b)
+}
+
+// digitRun returns the length of the run of digits at the start of text.
+func digitRun(text []rune) int {
+	n := 0
+	for n < len(text) && unicode.IsDigit(text[n]) {
+		n++
+	}
+	return n
+}
+
+// compareParsed compares text by the values that parse returns for it. Text that can't be parsed sorts after text
+// that can, in text order.
+func compareParsed(a, b string, parse func(string) (*big.Float, bool)) int {
+	x, okx := parse(a)
+	y, oky := parse(b)
+	switch {
+	case okx && oky:
+		if result := x.Cmp(y); result != 0 {
+			return result
+		}
+		return compareText(a, b)
+	case okx:
+		return -1
+	case oky:
+		return 1
+	default:
+		return compareText(a, b)
+	}
+}
+
+// parseNumber parses a decimal number (e.g. "-1.5", "1,024" or "010"), or an integer with a 0x, 0o or 0b prefix.
+func parseNumber(text string) (*big.Float, bool) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, ",", ""), "_", "")
+	if text == "" {
+		return nil, false
+	}
+	// Zero-padded numbers are decimal, not octal: only an explicit prefix selects another base.
+	base := 10
+	if digits := strings.ToLower(strings.TrimLeft(text, "+-")); len(digits) > 2 && digits[0] == '0' && strings.ContainsRune("xob", rune(digits[1])) {
+		base = 0
+	}
+	if n, ok := new(big.Int).SetString(text, base); ok {
+		return new(big.Float).SetInt(n), true
+	}
+	f, _, err := big.ParseFloat(text, 10, 64, big.ToNearestEven)
+	return f, err == nil
+}
+
+// parseHex parses a hexadecimal number, with or without a 0x prefix or an h suffix (e.g. "0x011F", "11F" or "11Fh").
+func parseHex(text string) (*big.Float, bool) {
+	text = strings.ReplaceAll(text, "_", "")
+	if t, ok := strings.CutPrefix(strings.ToLower(text), "0x"); ok {
+		text = t
+	} else if t, ok := strings.CutSuffix(strings.ToLower(text), "h"); ok {
+		text = t
+	}
+	n, ok := new(big.Int).SetString(text, 16)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Float).SetInt(n), true
+}
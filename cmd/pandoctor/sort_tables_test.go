@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSortOrders(t *testing.T) {
+	for _, tc := range []struct {
+		order string
+		text  []string
+		want  []string
+	}{
+		{
+			order: "numeric",
+			text:  []string{"010", "009", "008"},
+			want:  []string{"008", "009", "010"},
+		},
+		{
+			order: "numeric",
+			text:  []string{"0100", "99", "007", "0"},
+			want:  []string{"0", "007", "99", "0100"},
+		},
+		{
+			order: "numeric",
+			text:  []string{"5", "-10", "-2", "0", "-007"},
+			want:  []string{"-10", "-007", "-2", "0", "5"},
+		},
+		{
+			order: "numeric",
+			text:  []string{"1.5", "-0.25", "1.25", "01.75", "1"},
+			want:  []string{"-0.25", "1", "1.25", "1.5", "01.75"},
+		},
+		{
+			order: "numeric",
+			text:  []string{"0x10", "0o10", "0b10", "9", "-0x20"},
+			want:  []string{"-0x20", "0b10", "0o10", "9", "0x10"},
+		},
+		{
+			order: "numeric",
+			text:  []string{"1,024", "n/a", "999", "1_000"},
+			want:  []string{"999", "1_000", "1,024", "n/a"},
+		},
+		{
+			order: "hex",
+			text:  []string{"0x1F", "10h", "0A", "zz"},
+			want:  []string{"0A", "10h", "0x1F", "zz"},
+		},
+		{
+			order: "natural",
+			text:  []string{"Item 10", "item 9", "Item 09b"},
+			want:  []string{"item 9", "Item 09b", "Item 10"},
+		},
+	} {
+		t.Run(tc.order+"/"+strings.Join(tc.text, ","), func(t *testing.T) {
+			got := slices.Clone(tc.text)
+			slices.SortStableFunc(got, sortOrders[tc.order])
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("sorted diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+// tableOptionsRe matches a comment that sets options for the table just after it, e.g.
+// "<!-- pandoctor: widths=10,30,* align=l,l,r -->".
+var tableOptionsRe = regexp.MustCompile(`^<!-- pandoctor: (.*?) -->[ \t]*$`)
+
+// tableOptions are the settings for a single table, from the options comment just before it.
+type tableOptions struct {
+	// Whether every action should leave the table alone.
+	skip bool
+	// The widths of the columns, or nil to leave them to the action.
+	widths []gridtable.WidthSpec
+	// The alignments of the columns, or nil to keep them.
+	align []gridtable.Alignment
+	// The number of header rows, or -1 to keep it.
+	headerRows int
+}
+
+// changesTable returns whether the options change the layout of the table.
+func (opts *tableOptions) changesTable() bool {
+	return opts.widths != nil || opts.align != nil || opts.headerRows != -1
+}
+
+// parseTableOptions parses the space-separated options in an options comment. Each option is either "skip" or of the
+// form key=value.
+func parseTableOptions(s string) (*tableOptions, error) {
+	result := tableOptions{headerRows: -1}
+	for _, field := range strings.Fields(s) {
+		if field == "skip" {
+			result.skip = true
+			continue
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("table option %q is not of the form key=value", field)
+		}
+		var err error
+		switch key {
+		case "widths":
+			result.widths, err = gridtable.ParseWidthSpecs(value)
+		case "align":
+			result.align, err = parseAlignments(value)
+		case "header_rows":
+			result.headerRows, err = strconv.Atoi(value)
+			if err == nil && result.headerRows < 0 {
+				err = fmt.Errorf("header_rows must not be negative")
+			}
+		default:
+			err = fmt.Errorf("unknown table option %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid table option %q: %w", field, err)
+		}
+	}
+	return &result, nil
+}
+
+// parseAlignments parses a comma-separated list of column alignments.
+func parseAlignments(s string) ([]gridtable.Alignment, error) {
+	var result []gridtable.Alignment
+	for _, field := range strings.Split(s, ",") {
+		align, err := gridtable.ParseAlignment(field)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, align)
+	}
+	return result, nil
+}
+
+// tableOptionsAt returns the options for the table in the given block, from the options comment before it.
+// Blank lines and annotations may come between the comment and the table, and the comment may be indented or in a
+// block quote like the table.
+func tableOptionsAt(contents []byte, loc tableBlock) (*tableOptions, error) {
+	prefix := loc.prefix(contents)
+	before := trimBlankLines(contents[:loc.start], prefix)
+	for len(before) != 0 {
+		start := bytes.LastIndexByte(before, '\n') + 1
+		line := []byte(gridtable.StripPrefix(string(before[start:]), prefix))
+		if annotationRe.Match(line) {
+			before = trimBlankLines(before[:start], prefix)
+			continue
+		}
+		if isTableOptions(line) {
+			return parseTableOptions(string(tableOptionsRe.FindSubmatch(line)[1]))
+		}
+		break
+	}
+	return &tableOptions{headerRows: -1}, nil
+}
+
+// hasTableOptions returns whether the document has any options comments.
+func hasTableOptions(contents []byte) bool {
+	for offset := frontMatterEnd(contents); offset < len(contents); {
+		line, next := nextLine(contents, offset)
+		if isTableOptions(line[len(gridtable.LinePrefix(string(line))):]) {
+			return true
+		}
+		offset = next
+	}
+	return false
+}
+
+// isTableOptions returns whether the line is an options comment, as opposed to an annotation or a table marker.
+func isTableOptions(line []byte) bool {
+	return tableOptionsRe.Match(line) && !annotationRe.Match(line) && !tableMarkerRe.Match(line) && !tableEndMarkerRe.Match(line)
+}
+
+// apply updates the table with the options. tableWidth is the total width of the table used to resolve the widths.
+func (opts *tableOptions) apply(block *gridtable.Block, tableWidth int) error {
+	if opts.widths != nil {
+		cols, err := gridtable.ResolveWidths(opts.widths, block.Config.Columns, tableWidth)
+		if err != nil {
+			return err
+		}
+		block.Config.Columns = cols
+	}
+	if opts.align != nil {
+		if len(opts.align) != len(block.Config.Columns) {
+			return fmt.Errorf("%d alignments were given for a table with %d columns", len(opts.align), len(block.Config.Columns))
+		}
+		for j, align := range opts.align {
+			block.Config.Columns[j].Align = align
+		}
+	}
+	if opts.headerRows != -1 {
+		table, err := gridtable.NewTable(block.Config, block.Rows)
+		if err != nil {
+			return err
+		}
+		if err := table.SetNumHeaderRows(opts.headerRows); err != nil {
+			return err
+		}
+		block.Config = table.Config()
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTableOptionsAt(t *testing.T) {
+	const table = `+------+---+
+| Name | B |
++======+===+
+| x    | 1 |
++------+---+
+`
+	for _, tc := range []struct {
+		name     string
+		contents string
+		want     tableOptions
+	}{
+		{
+			name:     "no options",
+			contents: "# Title\n\n" + table,
+			want:     tableOptions{headerRows: -1},
+		},
+		{
+			name:     "options",
+			contents: "<!-- pandoctor: widths=6,* align=l,r header_rows=0 -->\n\n" + table,
+			want: tableOptions{
+				widths: []gridtable.WidthSpec{{Value: 6}, {Kind: gridtable.WidthRemaining}},
+				align:  []gridtable.Alignment{gridtable.AlignLeft, gridtable.AlignRight},
+			},
+		},
+		{
+			name:     "options before a caption",
+			contents: "<!-- pandoctor: skip -->\n\nTable: Names {#tbl-names}\n\n" + table,
+			want:     tableOptions{skip: true, headerRows: -1},
+		},
+		{
+			name:     "options after an annotation",
+			contents: "<!-- pandoctor: skip -->\n\n<!-- pandoctor: could not resize: something went wrong -->\n\n" + table,
+			want:     tableOptions{skip: true, headerRows: -1},
+		},
+		{
+			name:     "options in a block quote",
+			contents: gridtable.AddPrefix("<!-- pandoctor: header_rows=0 -->\n\n"+table, "> "),
+			want:     tableOptions{headerRows: 0},
+		},
+		{
+			name:     "options before a paragraph",
+			contents: "<!-- pandoctor: skip -->\n\nSome text.\n\n" + table,
+			want:     tableOptions{headerRows: -1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			blocks := findGridTables([]byte(tc.contents))
+			if len(blocks) != 1 {
+				t.Fatalf("findGridTables() found %d tables, want 1", len(blocks))
+			}
+			got, err := tableOptionsAt([]byte(tc.contents), blocks[0])
+			if err != nil {
+				t.Fatalf("tableOptionsAt() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, *got, cmp.AllowUnexported(tableOptions{})); diff != "" {
+				t.Errorf("tableOptionsAt() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTableOptionsOverrideResizeRule(t *testing.T) {
+	saveRules(t)
+	resizeRules = nil
+	setFlag(t, "match_columns", "Name,B")
+	setFlag(t, "new_widths", "10,10")
+	setFlag(t, "table_width", "30")
+	if err := validateResizeTablesArgs(); err != nil {
+		t.Fatalf("validateResizeTablesArgs() = %v", err)
+	}
+	got, err := resizeTables([]byte(`<!-- pandoctor: widths=6,* -->
+
++------+---+
+| Name | B |
++======+===+
+| x    | 1 |
++------+---+
+
++------+---+
+| Name | B |
++======+===+
+| y    | 2 |
++------+---+
+`))
+	if err != nil {
+		t.Fatalf("resizeTables() = %v", err)
+	}
+	// The first table is resized by its options, and the second by the rule.
+	want := `<!-- pandoctor: widths=6,* -->
+
++------+---------------------+
+| Name | B                   |
++======+=====================+
+| x    | 1                   |
++------+---------------------+
+
++----------+----------+
+| Name     | B        |
++==========+==========+
+| y        | 2        |
++----------+----------+
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("resizeTables() diff (-want +got)\n%v", diff)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+func transposeTables(contents []byte) ([]byte, error) {
+	matcher, err := tableMatcherFromFlags()
+	if err != nil {
+		return nil, err
+	}
+	if matcher == nil {
+		return nil, fmt.Errorf("transpose_tables requires --match_columns (or another --match_* flag) to select the tables to transpose")
+	}
+	return processTables(contents, "transpose_tables", findGridTables(contents), func(contents []byte, loc tableBlock) ([]byte, string, error) {
+		return transposeGridTable(contents, loc, matcher)
+	}), nil
+}
+
+// transposeGridTable swaps the rows and columns of the table if it matches, so that its first column becomes its
+// header row. The columns are made as wide as their contents need, up to --table_width in total. Column spans become
+// row spans and vice versa.
+func transposeGridTable(contents []byte, loc tableBlock, matcher *tableMatcher) ([]byte, string, error) {
+	block, err := gridtable.ReadBlock(string(loc.text(contents)))
+	if err != nil {
+		return nil, "", err
+	}
+	// We're not updating this table.
+	if !matcher.match(block) {
+		return loc.text(contents), statusSkipped, nil
+	}
+	table, err := gridtable.NewTable(block.Config, block.Rows)
+	if err != nil {
+		return nil, "", err
+	}
+	transposed, err := table.Transpose(1)
+	if err != nil {
+		return nil, "", fmt.Errorf("the first column can't become the header: %w", err)
+	}
+	transposed.FitColumns(*tableWidth)
+	block.Config = transposed.Config()
+	block.Rows = transposed.Rows()
+	result, status, err := renderBlock(contents, loc, block)
+	if errors.Is(err, gridtable.ErrBadWrap) {
+		return nil, "", fmt.Errorf("transposed table doesn't fit in %d characters: %w", *tableWidth, err)
+	}
+	return result, status, err
+}
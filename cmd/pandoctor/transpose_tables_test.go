@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTransposeTables(t *testing.T) {
+	setFlag(t, "match_columns", "Name")
+	setFlag(t, "match_prefix", "true")
+	setFlag(t, "table_width", "40")
+	got, err := transposeTables([]byte(`+------+-----+-----+
+| Name | Red | Sky |
++======+=====+=====+
+| Hue  | 0   | 200 |
++------+-----+-----+
+`))
+	if err != nil {
+		t.Fatalf("transposeTables() = %v", err)
+	}
+	want := `+------+-----+
+| Name | Hue |
++======+=====+
+| Red  | 0   |
++------+-----+
+| Sky  | 200 |
++------+-----+
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("transposeTables() diff (-want +got)\n%v", diff)
+	}
+	// The result can be read back.
+	if _, err := gridtable.ReadBlock(string(got)); err != nil {
+		t.Errorf("ReadBlock() = %v", err)
+	}
+}
+
+func TestTransposeTablesWithSpans(t *testing.T) {
+	setFlag(t, "match_columns", "Name")
+	setFlag(t, "match_prefix", "true")
+	setFlag(t, "table_width", "40")
+	got, err := transposeTables([]byte(`+------+-----+-----+
+| Name | Red | Sky |
++======+=====+=====+
+| Hue  | 0   | 200 |
++------+-----+-----+
+| Sat  | Both      |
++------+-----+-----+
+`))
+	if err != nil {
+		t.Fatalf("transposeTables() = %v", err)
+	}
+	// The column span becomes a row span.
+	want := `+------+-----+------+
+| Name | Hue | Sat  |
++======+=====+======+
+| Red  | 0   | Both |
++------+-----+      +
+| Sky  | 200 |      |
++------+-----+------+
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("transposeTables() diff (-want +got)\n%v", diff)
+	}
+	block, err := gridtable.ReadBlock(string(got))
+	if err != nil {
+		t.Fatalf("ReadBlock() = %v", err)
+	}
+	if cell := block.Rows[1][2]; cell == nil || cell.RowSpan != 1 || cell.ColSpan != 0 {
+		t.Errorf("ReadBlock() cell = %+v, want a cell spanning two rows", cell)
+	}
+	// Transposing it again gives back the original table.
+	again, err := transposeTables(got)
+	if err != nil {
+		t.Fatalf("transposeTables() = %v", err)
+	}
+	if !strings.Contains(string(again), "| Sat  | Both      |") {
+		t.Errorf("second transposeTables() = %v, want the original column span", string(again))
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/google/go-cmp v0.6.0
 	github.com/muesli/reflow v0.3.0
 	golang.org/x/net v0.29.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
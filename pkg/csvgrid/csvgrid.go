@@ -0,0 +1,86 @@
+// Package csvgrid converts CSV and TSV data into grid tables.
+package csvgrid
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	// ErrNoData indicates that there were no rows to put in the table.
+	ErrNoData = errors.New("no data")
+)
+
+const (
+	// The narrowest column that can be written (see gridtable.NewWriter).
+	minColumnWidth = 3
+)
+
+// Options control how the data is read.
+type Options struct {
+	// The field delimiter, e.g. ',' or '\t'. 0 means ','.
+	Comma rune
+	// Number of rows at the start of the data that form the header of the table.
+	NumHeaderRows int
+}
+
+// CommaForPath returns the field delimiter usually used by a file with the given name: a tab for .tsv and .tab files,
+// and a comma otherwise.
+func CommaForPath(path string) rune {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".tab":
+		return '\t'
+	default:
+		return ','
+	}
+}
+
+// Read reads CSV data into a grid table. Rows with fewer fields than the longest row are padded with empty cells.
+// All of the columns are as narrow as possible; use Table.FitColumns to choose their widths.
+func Read(r io.Reader, opts Options) (*gridtable.Table, error) {
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	// Spreadsheets don't always write the same number of fields in every row, and TSV files often contain stray quotes.
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = reader.Comma == '\t'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	numColumns := 0
+	for _, record := range records {
+		numColumns = max(numColumns, len(record))
+	}
+	if len(records) == 0 || numColumns == 0 {
+		return nil, ErrNoData
+	}
+	if opts.NumHeaderRows < 0 || opts.NumHeaderRows > len(records) {
+		return nil, fmt.Errorf("%w: %d header rows were requested, but there are %d rows", ErrNoData, opts.NumHeaderRows, len(records))
+	}
+	config := gridtable.Config{
+		NumHeaderRows: opts.NumHeaderRows,
+		Columns:       make([]gridtable.ColumnSpec, numColumns),
+	}
+	for j := range config.Columns {
+		config.Columns[j].Width = minColumnWidth
+	}
+	rows := make([][]*gridtable.Cell, len(records))
+	for i, record := range records {
+		rows[i] = make([]*gridtable.Cell, numColumns)
+		for j := range rows[i] {
+			rows[i][j] = &gridtable.Cell{}
+			if j < len(record) {
+				rows[i][j].Text = strings.TrimSpace(record[j])
+			}
+		}
+	}
+	return gridtable.NewTable(config, rows)
+}
@@ -0,0 +1,78 @@
+package csvgrid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRead(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+		opts Options
+		want string
+	}{
+		{
+			name: "csv",
+			data: "Name,Value\nalpha,1\n\"beta, gamma\",2\n",
+			opts: Options{NumHeaderRows: 1},
+			want: `+-------------+-------+
+| Name        | Value |
++=============+=======+
+| alpha       | 1     |
++-------------+-------+
+| beta, gamma | 2     |
++-------------+-------+
+`,
+		},
+		{
+			name: "tsv with a short row",
+			data: "a\tb, c\tc\nd\n",
+			opts: Options{Comma: '\t'},
+			want: `+---+------+---+
+| a | b, c | c |
++---+------+---+
+| d |      |   |
++---+------+---+
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			table, err := Read(strings.NewReader(tc.data), tc.opts)
+			if err != nil {
+				t.Fatalf("Read() = %v", err)
+			}
+			table.FitColumns(80)
+			got, err := table.String()
+			if err != nil {
+				t.Fatalf("String() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("String() =\n%v\nwant:\n%v\ndiff (-want +got)\n%v", got, tc.want, diff)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	if _, err := Read(strings.NewReader(""), Options{}); !errors.Is(err, ErrNoData) {
+		t.Errorf("Read() = %v, want %v", err, ErrNoData)
+	}
+	if _, err := Read(strings.NewReader("a\n"), Options{NumHeaderRows: 2}); !errors.Is(err, ErrNoData) {
+		t.Errorf("Read() = %v, want %v", err, ErrNoData)
+	}
+	if _, err := Read(strings.NewReader("\"a\n"), Options{}); err == nil {
+		t.Errorf("Read() of unterminated quote = nil, want error")
+	}
+}
+
+func TestCommaForPath(t *testing.T) {
+	for path, want := range map[string]rune{"data.csv": ',', "data.TSV": '\t', "data": ','} {
+		if got := CommaForPath(path); got != want {
+			t.Errorf("CommaForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package datagrid converts structured data (YAML or JSON) into grid tables.
+//
+// The data is a list of records, each of which becomes a row of the table. By default, the columns are the fields of
+// the first record, in order. A Template can instead choose the headings of the columns and compute their contents
+// from each record with Go templates.
+package datagrid
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrNoRecords indicates that the data doesn't contain a list of records.
+	ErrNoRecords = errors.New("no records")
+)
+
+const (
+	// The narrowest column that can be written (see gridtable.NewWriter).
+	minColumnWidth = 3
+)
+
+// A Template describes how to lay out the records as a table.
+type Template struct {
+	// The caption and ID of the table, if any.
+	Caption string `yaml:"caption"`
+	ID      string `yaml:"id"`
+	// The columns of the table.
+	Columns []Column `yaml:"columns"`
+}
+
+// A Column is a column of a Template.
+type Column struct {
+	// The heading of the column.
+	Heading string `yaml:"heading"`
+	// A Go template that computes the contents of the column from each record, e.g. `{{printf "0x%02X" .offset}}`.
+	// Fields that the record doesn't have are empty. The default is the field named by the heading.
+	Value string `yaml:"value"`
+	// The width of the column. If no column has a width, the columns are fitted to their contents.
+	Width *gridtable.WidthSpec `yaml:"width"`
+}
+
+// ParseTemplate parses a Template from YAML (or JSON).
+func ParseTemplate(data []byte) (*Template, error) {
+	var result Template
+	if err := yaml.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Columns) == 0 {
+		return nil, fmt.Errorf("template has no columns")
+	}
+	return &result, nil
+}
+
+// Widths returns the widths of the columns, or nil if none of the columns have a width. Columns without a width take
+// the remaining space.
+func (t *Template) Widths() []gridtable.WidthSpec {
+	var result []gridtable.WidthSpec
+	set := false
+	for _, col := range t.Columns {
+		if col.Width == nil {
+			result = append(result, gridtable.WidthSpec{Kind: gridtable.WidthRemaining})
+			continue
+		}
+		result = append(result, *col.Width)
+		set = true
+	}
+	if !set {
+		return nil
+	}
+	return result
+}
+
+// Records finds the list of records in YAML (or JSON) data. path is a dot-separated list of keys leading to the list,
+// or "" if the data is the list itself.
+func Records(data []byte, path string) ([]*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, ErrNoRecords
+	}
+	node := doc.Content[0]
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			next := field(node, key)
+			if next == nil {
+				return nil, fmt.Errorf("%w: no field %q on the path %q", ErrNoRecords, key, path)
+			}
+			node = next
+		}
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%w: expected a list at line %d", ErrNoRecords, node.Line)
+	}
+	for _, record := range node.Content {
+		if record.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%w: expected a record at line %d", ErrNoRecords, record.Line)
+		}
+	}
+	return node.Content, nil
+}
+
+// field returns the value of the given key in a mapping node, or nil if there isn't one.
+func field(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// DefaultTemplate returns a template with a column for each field of the first record.
+func DefaultTemplate(records []*yaml.Node) *Template {
+	var result Template
+	if len(records) == 0 {
+		return &result
+	}
+	for i := 0; i < len(records[0].Content); i += 2 {
+		result.Columns = append(result.Columns, Column{Heading: records[0].Content[i].Value})
+	}
+	return &result
+}
+
+// Table lays out the records as a table with a header row, according to the template. All of the columns are as narrow
+// as possible; use Table.FitColumns or gridtable.ResolveWidths with Template.Widths to choose their widths.
+func Table(records []*yaml.Node, tmpl *Template) (*gridtable.Table, error) {
+	if len(tmpl.Columns) == 0 {
+		return nil, fmt.Errorf("%w: there are no columns", ErrNoRecords)
+	}
+	values := make([]*template.Template, len(tmpl.Columns))
+	for j, col := range tmpl.Columns {
+		text := col.Value
+		if text == "" {
+			text = fmt.Sprintf("{{index . %q}}", col.Heading)
+		}
+		var err error
+		if values[j], err = template.New(col.Heading).Option("missingkey=zero").Parse(text); err != nil {
+			return nil, fmt.Errorf("column %q: %w", col.Heading, err)
+		}
+	}
+	config := gridtable.Config{
+		NumHeaderRows: 1,
+		Columns:       make([]gridtable.ColumnSpec, len(tmpl.Columns)),
+	}
+	header := make([]*gridtable.Cell, len(tmpl.Columns))
+	for j, col := range tmpl.Columns {
+		config.Columns[j].Width = minColumnWidth
+		header[j] = &gridtable.Cell{Text: col.Heading}
+	}
+	rows := [][]*gridtable.Cell{header}
+	for _, record := range records {
+		var data map[string]any
+		if err := record.Decode(&data); err != nil {
+			return nil, fmt.Errorf("record at line %d: %w", record.Line, err)
+		}
+		row := make([]*gridtable.Cell, len(tmpl.Columns))
+		for j, value := range values {
+			var sb strings.Builder
+			if err := value.Execute(&sb, data); err != nil {
+				return nil, fmt.Errorf("record at line %d: column %q: %w", record.Line, tmpl.Columns[j].Heading, err)
+			}
+			// Missing fields of a map are printed as "<no value>", even with missingkey=zero.
+			row[j] = &gridtable.Cell{Text: strings.TrimSpace(strings.ReplaceAll(sb.String(), "<no value>", ""))}
+		}
+		rows = append(rows, row)
+	}
+	return gridtable.NewTable(config, rows)
+}
@@ -0,0 +1,136 @@
+package datagrid
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/google/go-cmp/cmp"
+)
+
+const registers = `
+device: widget
+registers:
+  - name: CTRL
+    offset: 0x00
+    access: RW
+  - name: STATUS
+    offset: 0x04
+    access: RO
+    description: Current status.
+`
+
+func tableText(t *testing.T, table *gridtable.Table) [][]string {
+	t.Helper()
+	var result [][]string
+	for _, row := range table.Rows() {
+		var texts []string
+		for _, cell := range row {
+			texts = append(texts, cell.Text)
+		}
+		result = append(result, texts)
+	}
+	return result
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	records, err := Records([]byte(registers), "registers")
+	if err != nil {
+		t.Fatalf("Records() = %v", err)
+	}
+	table, err := Table(records, DefaultTemplate(records))
+	if err != nil {
+		t.Fatalf("Table() = %v", err)
+	}
+	want := [][]string{
+		{"name", "offset", "access"},
+		{"CTRL", "0", "RW"},
+		{"STATUS", "4", "RO"},
+	}
+	if diff := cmp.Diff(want, tableText(t, table)); diff != "" {
+		t.Errorf("Table() (-want +got):\n%v", diff)
+	}
+	if got := table.Config().NumHeaderRows; got != 1 {
+		t.Errorf("NumHeaderRows = %v, want 1", got)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(`
+caption: Registers
+columns:
+  - heading: Offset
+    value: '{{printf "0x%02X" .offset}}'
+    width: 10
+  - heading: Name
+    value: '{{.name}} ({{.access}})'
+  - heading: Description
+    value: '{{.description}}'
+    width: '*'
+`))
+	if err != nil {
+		t.Fatalf("ParseTemplate() = %v", err)
+	}
+	wantWidths := []gridtable.WidthSpec{
+		{Kind: gridtable.WidthAbsolute, Value: 10},
+		{Kind: gridtable.WidthRemaining},
+		{Kind: gridtable.WidthRemaining},
+	}
+	if diff := cmp.Diff(wantWidths, tmpl.Widths()); diff != "" {
+		t.Errorf("Widths() (-want +got):\n%v", diff)
+	}
+	records, err := Records([]byte(registers), "registers")
+	if err != nil {
+		t.Fatalf("Records() = %v", err)
+	}
+	table, err := Table(records, tmpl)
+	if err != nil {
+		t.Fatalf("Table() = %v", err)
+	}
+	want := [][]string{
+		{"Offset", "Name", "Description"},
+		{"0x00", "CTRL (RW)", ""},
+		{"0x04", "STATUS (RO)", "Current status."},
+	}
+	if diff := cmp.Diff(want, tableText(t, table)); diff != "" {
+		t.Errorf("Table() (-want +got):\n%v", diff)
+	}
+}
+
+func TestRecordsFromJSON(t *testing.T) {
+	records, err := Records([]byte(`[{"b": 1, "a": "x"}, {"a": "y"}]`), "")
+	if err != nil {
+		t.Fatalf("Records() = %v", err)
+	}
+	table, err := Table(records, DefaultTemplate(records))
+	if err != nil {
+		t.Fatalf("Table() = %v", err)
+	}
+	want := [][]string{
+		{"b", "a"},
+		{"1", "x"},
+		{"", "y"},
+	}
+	if diff := cmp.Diff(want, tableText(t, table)); diff != "" {
+		t.Errorf("Table() (-want +got):\n%v", diff)
+	}
+}
+
+func TestRecordsErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+		path string
+	}{
+		{"empty", "", ""},
+		{"not a list", "a: 1", ""},
+		{"missing path", "a: []", "b"},
+		{"not records", "- 1\n- 2", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Records([]byte(tc.data), tc.path); !errors.Is(err, ErrNoRecords) {
+				t.Errorf("Records() = %v, want %v", err, ErrNoRecords)
+			}
+		})
+	}
+}
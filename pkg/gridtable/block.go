@@ -0,0 +1,239 @@
+package gridtable
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Block is a grid table together with its caption, as it appears in a Pandoc Markdown document.
+type Block struct {
+	// The configuration of the table.
+	Config Config
+	// The rows of the table. A nil entry indicates a shadowed cell.
+	Rows [][]*Cell
+	// The caption of the table, or nil if it has none.
+	Caption *Caption
+	// Where the caption appears relative to the table.
+	CaptionPosition CaptionPosition
+	// The text at the start of every line of the block, e.g. "> " in a block quote or indentation in a list item.
+	Prefix string
+}
+
+// linePrefixRe matches the indentation and block quote markers at the start of a line.
+var linePrefixRe = regexp.MustCompile(`^[ \t>]*`)
+
+// LinePrefix returns the indentation and block quote markers at the start of a line.
+func LinePrefix(line string) string {
+	return linePrefixRe.FindString(line)
+}
+
+// StripPrefix removes prefix from the start of each line of text. Blank lines may have just the start of prefix, e.g.
+// ">" for "> ". Other lines that don't start with prefix lose as much of it as they have.
+func StripPrefix(text string, prefix string) string {
+	if prefix == "" {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		n := 0
+		for n < len(line) && n < len(prefix) && line[n] == prefix[n] {
+			n++
+		}
+		lines[i] = line[n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AddPrefix adds prefix to the start of each line of text. Blank lines get prefix without its trailing whitespace.
+func AddPrefix(text string, prefix string) string {
+	if prefix == "" {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i == len(lines)-1 && line == "" {
+			// Don't add anything after the final newline.
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			lines[i] = strings.TrimRight(prefix, " \t")
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ReadTable reads an entire grid table, returning its configuration and rows.
+func ReadTable(r io.Reader) (*Config, [][]*Cell, error) {
+	reader, err := NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return readTable(reader)
+}
+
+func readTable(reader *Reader) (*Config, [][]*Cell, error) {
+	var rows [][]*Cell
+	for row, err := range reader.Read() {
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, row)
+	}
+	config, err := reader.GetConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, rows, nil
+}
+
+// ReadBlock reads a table block: a grid table, optionally preceded or followed by a caption paragraph.
+func ReadBlock(text string) (*Block, error) {
+	block, _, err := readBlock(text, NewReader)
+	return block, err
+}
+
+// ReadLenientBlock is like ReadBlock, but reads the table with a lenient Reader (see NewLenientReader). It also returns
+// what was repaired. The line numbers of the repairs are relative to the start of the table.
+func ReadLenientBlock(text string) (*Block, []Repair, error) {
+	return readBlock(text, NewLenientReader)
+}
+
+func readBlock(text string, newReader func(io.Reader) (*Reader, error)) (*Block, []Repair, error) {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	// The table is the first run of lines that begin with '+' or '|' after the prefix of its first line.
+	var prefix string
+	for _, line := range strings.Split(text, "\n") {
+		if prefix = LinePrefix(line); strings.HasPrefix(line[len(prefix):], "+") {
+			break
+		}
+	}
+	original := strings.Split(text, "\n")
+	lines := strings.Split(StripPrefix(text, prefix), "\n")
+	first := 0
+	for first < len(lines) && !strings.HasPrefix(lines[first], "+") {
+		first++
+	}
+	if first == len(lines) {
+		return nil, nil, fmt.Errorf("%w: no table was found", ErrMalformedTable)
+	}
+	// Every line of the table has the whole prefix.
+	last := first
+	for last+1 < len(lines) && strings.HasPrefix(original[last+1], prefix) && (strings.HasPrefix(lines[last+1], "+") || strings.HasPrefix(lines[last+1], "|")) {
+		last++
+	}
+	before := strings.TrimSpace(strings.Join(lines[:first], "\n"))
+	after := strings.TrimSpace(strings.Join(lines[last+1:], "\n"))
+
+	result := Block{Prefix: prefix}
+	var caption string
+	switch {
+	case before != "" && after != "":
+		return nil, nil, fmt.Errorf("%w: expected a table with an optional caption before or after it", ErrMalformedTable)
+	case before != "":
+		result.CaptionPosition = CaptionBefore
+		caption = before
+	case after != "":
+		result.CaptionPosition = CaptionAfter
+		caption = after
+	}
+	if caption != "" {
+		var err error
+		if result.Caption, err = ParseCaption(caption); err != nil {
+			return nil, nil, err
+		}
+	}
+	reader, err := newReader(strings.NewReader(strings.Join(lines[first:last+1], "\n") + "\n"))
+	if err != nil {
+		return nil, nil, err
+	}
+	config, rows, err := readTable(reader)
+	var tableErr *TableError
+	if errors.As(err, &tableErr) && tableErr.Line != 0 && prefix != "" {
+		// Report the position within the original line.
+		fileErr := *tableErr
+		fileErr.Text = prefix + fileErr.Text
+		if fileErr.Column != 0 {
+			fileErr.Column += utf8.RuneCountInString(prefix)
+		}
+		err = &fileErr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	result.Config = *config
+	result.Rows = rows
+	return &result, reader.Repairs(), nil
+}
+
+// Headings returns the text of the cells in the first row of the table. Shadowed cells are represented as "".
+func (b *Block) Headings() []string {
+	if len(b.Rows) == 0 {
+		return nil
+	}
+	result := make([]string, len(b.Rows[0]))
+	for j, cell := range b.Rows[0] {
+		if cell != nil {
+			result[j] = cell.Text
+		}
+	}
+	return result
+}
+
+// TableString renders just the grid table, without the caption.
+func (b *Block) TableString() (string, error) {
+	w, err := NewWriter(b.Config)
+	if err != nil {
+		return "", err
+	}
+	for i, row := range b.Rows {
+		if i != 0 {
+			w.NextRow()
+		}
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			if err := w.WriteColumn(j, *cell); err != nil {
+				return "", err
+			}
+		}
+	}
+	table, err := w.String()
+	if err != nil {
+		return "", err
+	}
+	return AddPrefix(table, b.Prefix), nil
+}
+
+// Width returns the total width of the table in characters.
+func (b *Block) Width() int {
+	return calculateTableWidth(b.Config.Columns)
+}
+
+// String renders the block with the caption (if any) on a single line.
+func (b *Block) String() (string, error) {
+	return b.Format(0)
+}
+
+// Format renders the block: the grid table and the caption (if any) wrapped to captionWidth, separated by a blank line.
+// A captionWidth of 0 means the caption is not wrapped.
+func (b *Block) Format(captionWidth int) (string, error) {
+	table, err := b.TableString()
+	if err != nil {
+		return "", err
+	}
+	if b.Caption == nil {
+		return table, nil
+	}
+	caption := b.Caption.Format(captionWidth)
+	if b.CaptionPosition == CaptionAfter {
+		return table + AddPrefix("\n"+caption+"\n", b.Prefix), nil
+	}
+	return AddPrefix(caption+"\n\n", b.Prefix) + table, nil
+}
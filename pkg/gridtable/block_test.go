@@ -0,0 +1,106 @@
+package gridtable
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadBlock(t *testing.T) {
+	table := `+---+---+
+| A | B |
++===+===+
+| C | D |
++---+---+
+`
+	for i, tc := range []struct {
+		str          string
+		wantCaption  *Caption
+		wantPosition CaptionPosition
+		wantPrefix   string
+	}{
+		{
+			str: table,
+		},
+		{
+			str: "Table: Letters {#tbl-letters}\n\n" + table,
+			wantCaption: &Caption{
+				Prefix: "Table:",
+				Text:   "Letters",
+				Attributes: Attributes{
+					ID: "tbl-letters",
+				},
+			},
+			wantPosition: CaptionBefore,
+		},
+		{
+			str: table + "\n: Letters\n",
+			wantCaption: &Caption{
+				Prefix: ":",
+				Text:   "Letters",
+			},
+			wantPosition: CaptionAfter,
+		},
+		{
+			str: "> Table: Letters\n>\n> +---+---+\n> | A | B |\n> +===+===+\n> | C | D |\n> +---+---+\n",
+			wantCaption: &Caption{
+				Prefix: "Table:",
+				Text:   "Letters",
+			},
+			wantPosition: CaptionBefore,
+			wantPrefix:   "> ",
+		},
+		{
+			str: "    +---+---+\n    | A | B |\n    +===+===+\n    | C | D |\n    +---+---+\n\n    : Letters\n",
+			wantCaption: &Caption{
+				Prefix: ":",
+				Text:   "Letters",
+			},
+			wantPosition: CaptionAfter,
+			wantPrefix:   "    ",
+		},
+	} {
+		t.Run(fmt.Sprintf("block_%v", i), func(t *testing.T) {
+			got, err := ReadBlock(tc.str)
+			if err != nil {
+				t.Fatalf("ReadBlock() = %v", err)
+			}
+			if !cmp.Equal(got.Caption, tc.wantCaption) {
+				t.Errorf("ReadBlock() caption = %v, want %v", got.Caption, tc.wantCaption)
+			}
+			if got.CaptionPosition != tc.wantPosition {
+				t.Errorf("ReadBlock() caption position = %v, want %v", got.CaptionPosition, tc.wantPosition)
+			}
+			if got.Prefix != tc.wantPrefix {
+				t.Errorf("ReadBlock() prefix = %q, want %q", got.Prefix, tc.wantPrefix)
+			}
+			if diff := cmp.Diff([]string{"A", "B"}, got.Headings()); diff != "" {
+				t.Errorf("Headings() diff (-want +got)\n%v", diff)
+			}
+			// The block should render back to exactly what we read.
+			str, err := got.String()
+			if err != nil {
+				t.Fatalf("String() = %v", err)
+			}
+			if diff := cmp.Diff(tc.str, str); diff != "" {
+				t.Errorf("String() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestReadMalformedBlock(t *testing.T) {
+	for i, tc := range []string{
+		"Just a paragraph\n",
+		"Not a caption\n\n+---+\n| A |\n+---+\n",
+		"Table: One\n\n+---+\n| A |\n+---+\n\nTable: Two\n",
+		"> +---+\n> | A |\n+---+\n",
+	} {
+		t.Run(fmt.Sprintf("block_%v", i), func(t *testing.T) {
+			if got, err := ReadBlock(tc); err == nil {
+				t.Errorf("ReadBlock() = %+v, want error", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package gridtable
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// Attributes are the Pandoc attributes of an element, e.g. {#id .class key=val}.
+type Attributes struct {
+	// The identifier, without the '#'.
+	ID string
+	// The classes, without the '.'s.
+	Classes []string
+	// The key-value pairs, in order.
+	KeyVals [][2]string
+}
+
+// IsEmpty returns whether there are no attributes.
+func (a *Attributes) IsEmpty() bool {
+	return a.ID == "" && len(a.Classes) == 0 && len(a.KeyVals) == 0
+}
+
+// Get returns the value for the given key, or "" if there is none.
+func (a *Attributes) Get(key string) string {
+	for _, kv := range a.KeyVals {
+		if kv[0] == key {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+// String renders the attributes in curly braces, or returns "" if there are none.
+func (a *Attributes) String() string {
+	if a.IsEmpty() {
+		return ""
+	}
+	var fields []string
+	if a.ID != "" {
+		fields = append(fields, "#"+a.ID)
+	}
+	for _, class := range a.Classes {
+		fields = append(fields, "."+class)
+	}
+	for _, kv := range a.KeyVals {
+		fields = append(fields, kv[0]+"="+quoteValue(kv[1]))
+	}
+	return "{" + strings.Join(fields, " ") + "}"
+}
+
+// quoteValue quotes an attribute value if it needs it. As in Pandoc, '"' and '\' are escaped with a '\' within
+// quotes.
+func quoteValue(val string) string {
+	if val != "" && !strings.ContainsAny(val, " \t\n\"\\{}") {
+		return val
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(val) + `"`
+}
+
+// ParseAttributes parses attributes of the form {#id .class key=val key2="quoted val"}. Within a quoted value, a
+// '\' escapes the next character.
+func ParseAttributes(s string) (*Attributes, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("attributes must be enclosed in curly braces: %q", s)
+	}
+	s = s[1 : len(s)-1]
+	var result Attributes
+	for {
+		s = strings.TrimLeft(s, " \t\n")
+		if s == "" {
+			return &result, nil
+		}
+		end := strings.IndexAny(s, " \t\n=")
+		if end == -1 {
+			end = len(s)
+		}
+		field := s[:end]
+		s = s[end:]
+		switch {
+		case strings.HasPrefix(field, "#"):
+			if len(field) == 1 {
+				return nil, fmt.Errorf("empty identifier in attributes")
+			}
+			result.ID = field[1:]
+		case strings.HasPrefix(field, "."):
+			if len(field) == 1 {
+				return nil, fmt.Errorf("empty class in attributes")
+			}
+			result.Classes = append(result.Classes, field[1:])
+		case strings.HasPrefix(s, "="):
+			s = s[1:]
+			var val string
+			if strings.HasPrefix(s, "\"") {
+				var ok bool
+				val, s, ok = unquoteValue(s)
+				if !ok {
+					return nil, fmt.Errorf("unterminated quoted value for attribute %q", field)
+				}
+			} else {
+				end := strings.IndexAny(s, " \t\n")
+				if end == -1 {
+					end = len(s)
+				}
+				val = s[:end]
+				s = s[end:]
+			}
+			result.KeyVals = append(result.KeyVals, [2]string{field, val})
+		default:
+			return nil, fmt.Errorf("unexpected %q in attributes", field)
+		}
+	}
+}
+
+// unquoteValue reads the quoted value at the start of s, and returns it and the rest of s. It returns false if the
+// value isn't terminated.
+func unquoteValue(s string) (string, string, bool) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return sb.String(), s[i+1:], true
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return "", "", false
+}
+
+// CaptionPosition indicates where a table's caption appears relative to the table.
+type CaptionPosition int
+
+const (
+	// NoCaption indicates that the table does not have a caption.
+	NoCaption CaptionPosition = iota
+	// CaptionBefore indicates that the caption paragraph comes before the table.
+	CaptionBefore
+	// CaptionAfter indicates that the caption paragraph comes after the table.
+	CaptionAfter
+)
+
+// A Caption is the caption of a table, from a Pandoc Markdown paragraph like "Table: Some text {#id .class}".
+type Caption struct {
+	// The prefix that introduced the caption: "Table:", "table:" or ":".
+	Prefix string
+	// The text of the caption, with whitespace normalized.
+	Text string
+	// The attributes at the end of the caption, if any.
+	Attributes Attributes
+}
+
+var (
+	captionPrefixRe     = regexp.MustCompile(`^(Table:|table:|:)(\s|$)`)
+	captionAttributesRe = regexp.MustCompile(`\s*(\{[^{}]*\})\s*$`)
+)
+
+// IsCaption returns whether the paragraph is a table caption.
+func IsCaption(paragraph string) bool {
+	return captionPrefixRe.MatchString(paragraph)
+}
+
+// ParseCaption parses a caption paragraph.
+func ParseCaption(paragraph string) (*Caption, error) {
+	m := captionPrefixRe.FindStringSubmatch(paragraph)
+	if m == nil {
+		return nil, fmt.Errorf("caption must begin with \"Table:\" or \":\"")
+	}
+	result := Caption{
+		Prefix: m[1],
+	}
+	text := paragraph[len(m[1]):]
+	if loc := captionAttributesRe.FindStringSubmatchIndex(text); loc != nil {
+		// Like Pandoc, treat anything in braces that isn't valid attributes as part of the text.
+		if attrs, err := ParseAttributes(text[loc[2]:loc[3]]); err == nil {
+			result.Attributes = *attrs
+			text = text[:loc[0]]
+		}
+	}
+	result.Text = strings.Join(strings.Fields(text), " ")
+	return &result, nil
+}
+
+// String renders the caption as a single line.
+func (c *Caption) String() string {
+	return c.Format(0)
+}
+
+// Format renders the caption as a paragraph wrapped to the given width. A width of 0 means no wrapping.
+func (c *Caption) Format(width int) string {
+	prefix := c.Prefix
+	if prefix == "" {
+		prefix = "Table:"
+	}
+	fields := []string{prefix}
+	if c.Text != "" {
+		fields = append(fields, c.Text)
+	}
+	if attrs := c.Attributes.String(); attrs != "" {
+		fields = append(fields, attrs)
+	}
+	result := strings.Join(fields, " ")
+	if width == 0 {
+		return result
+	}
+	return wordwrap.String(result, width)
+}
@@ -0,0 +1,186 @@
+package gridtable
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCaption(t *testing.T) {
+	for i, tc := range []struct {
+		str  string
+		want Caption
+	}{
+		{
+			str: "Table: A caption",
+			want: Caption{
+				Prefix: "Table:",
+				Text:   "A caption",
+			},
+		},
+		{
+			str: ": A caption\nwrapped onto two lines {#tbl-id}",
+			want: Caption{
+				Prefix: ":",
+				Text:   "A caption wrapped onto two lines",
+				Attributes: Attributes{
+					ID: "tbl-id",
+				},
+			},
+		},
+		{
+			str: `table: Registers {#tbl-regs .wide .striped key=val other="two words"}`,
+			want: Caption{
+				Prefix: "table:",
+				Text:   "Registers",
+				Attributes: Attributes{
+					ID:      "tbl-regs",
+					Classes: []string{"wide", "striped"},
+					KeyVals: [][2]string{{"key", "val"}, {"other", "two words"}},
+				},
+			},
+		},
+		{
+			str: "Table: Not attributes {#}",
+			want: Caption{
+				Prefix: "Table:",
+				Text:   "Not attributes {#}",
+			},
+		},
+		{
+			str: "Table: {#tbl-empty}",
+			want: Caption{
+				Prefix: "Table:",
+				Attributes: Attributes{
+					ID: "tbl-empty",
+				},
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("caption_%v", i), func(t *testing.T) {
+			got, err := ParseCaption(tc.str)
+			if err != nil {
+				t.Fatalf("ParseCaption() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, *got); diff != "" {
+				t.Errorf("ParseCaption() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestParseInvalidCaption(t *testing.T) {
+	for i, tc := range []string{
+		"Not a caption",
+		"Tables: not a caption either",
+	} {
+		t.Run(fmt.Sprintf("caption_%v", i), func(t *testing.T) {
+			if got, err := ParseCaption(tc); err == nil {
+				t.Errorf("ParseCaption() = %v, want error", got)
+			}
+		})
+	}
+}
+
+func TestFormatCaption(t *testing.T) {
+	for i, tc := range []struct {
+		caption Caption
+		width   int
+		want    string
+	}{
+		{
+			caption: Caption{
+				Text: "A caption",
+			},
+			want: "Table: A caption",
+		},
+		{
+			caption: Caption{
+				Prefix: ":",
+				Text:   "Registers",
+				Attributes: Attributes{
+					ID:      "tbl-regs",
+					Classes: []string{"wide"},
+					KeyVals: [][2]string{{"note", "two words"}},
+				},
+			},
+			want: `: Registers {#tbl-regs .wide note="two words"}`,
+		},
+		{
+			caption: Caption{
+				Prefix: "Table:",
+				Text:   "A caption that is long enough to need wrapping",
+			},
+			width: 20,
+			want:  "Table: A caption\nthat is long enough\nto need wrapping",
+		},
+	} {
+		t.Run(fmt.Sprintf("caption_%v", i), func(t *testing.T) {
+			got := tc.caption.Format(tc.width)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Format() diff (-want +got)\n%v", diff)
+			}
+			// Round-trip the caption.
+			parsed, err := ParseCaption(got)
+			if err != nil {
+				t.Fatalf("ParseCaption() = %v", err)
+			}
+			if parsed.Text != tc.caption.Text || !cmp.Equal(parsed.Attributes, tc.caption.Attributes) {
+				t.Errorf("ParseCaption() = %+v, want %+v", parsed, tc.caption)
+			}
+		})
+	}
+}
+
+func TestAttributesRoundTrip(t *testing.T) {
+	for i, tc := range []struct {
+		attrs Attributes
+		want  string
+	}{
+		{
+			attrs: Attributes{KeyVals: [][2]string{{"key", "val"}, {"empty", ""}}},
+			want:  `{key=val empty=""}`,
+		},
+		{
+			attrs: Attributes{KeyVals: [][2]string{{"title", `say "hi"`}}},
+			want:  `{title="say \"hi\""}`,
+		},
+		{
+			attrs: Attributes{KeyVals: [][2]string{{"path", `C:\data`}, {"quote", `"`}}},
+			want:  `{path="C:\\data" quote="\""}`,
+		},
+		{
+			attrs: Attributes{ID: "tbl-x", KeyVals: [][2]string{{"note", "tab\there"}, {"unicode", "café"}}},
+			want:  "{#tbl-x note=\"tab\there\" unicode=café}",
+		},
+	} {
+		t.Run(fmt.Sprintf("attributes_%v", i), func(t *testing.T) {
+			got := tc.attrs.String()
+			if got != tc.want {
+				t.Errorf("String() = %v, want %v", got, tc.want)
+			}
+			parsed, err := ParseAttributes(got)
+			if err != nil {
+				t.Fatalf("ParseAttributes(%q) = %v", got, err)
+			}
+			if diff := cmp.Diff(tc.attrs, *parsed); diff != "" {
+				t.Errorf("ParseAttributes(%q) diff (-want +got)\n%v", got, diff)
+			}
+		})
+	}
+}
+
+func TestParseAttributesEscapes(t *testing.T) {
+	got, err := ParseAttributes(`{a="one \"two\" \\ three" b=plain}`)
+	if err != nil {
+		t.Fatalf("ParseAttributes() = %v", err)
+	}
+	want := [][2]string{{"a", `one "two" \ three`}, {"b", "plain"}}
+	if diff := cmp.Diff(want, got.KeyVals); diff != "" {
+		t.Errorf("ParseAttributes() diff (-want +got)\n%v", diff)
+	}
+	if _, err := ParseAttributes(`{a="unterminated \"}`); err == nil {
+		t.Errorf("ParseAttributes() succeeded with an escaped closing quote, want an error")
+	}
+}
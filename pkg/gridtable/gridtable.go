@@ -31,6 +31,78 @@ var (
 	ErrReaderNotDone = errors.New("reader not done reading table")
 )
 
+// A TableError is an error at a particular position within a grid table.
+// It wraps one of the sentinel errors above (usually ErrMalformedTable).
+type TableError struct {
+	// The underlying error.
+	Err error
+	// The (1-based) line number within the table. 0 = unknown.
+	Line int
+	// The (1-based) column number within the line, in characters. 0 = unknown.
+	Column int
+	// The text of the offending line.
+	Text string
+}
+
+// errorAt returns a TableError at the given position wrapping the given sentinel error.
+func errorAt(line int, column int, text string, sentinel error, format string, args ...any) *TableError {
+	return &TableError{
+		Err:    fmt.Errorf("%w: %v", sentinel, fmt.Sprintf(format, args...)),
+		Line:   line,
+		Column: column,
+		Text:   text,
+	}
+}
+
+// Error implements error.
+func (e *TableError) Error() string {
+	switch {
+	case e.Line == 0:
+		return e.Err.Error()
+	case e.Column == 0:
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	default:
+		return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+	}
+}
+
+// Unwrap allows the underlying error to be inspected with errors.Is and errors.As.
+func (e *TableError) Unwrap() error {
+	return e.Err
+}
+
+// Snippet renders the offending line with a caret under the offending column, e.g.:
+//
+//	| A | B
+//	       ^
+//
+// It returns "" if the position is unknown.
+func (e *TableError) Snippet() string {
+	if e.Line == 0 {
+		return ""
+	}
+	if e.Column == 0 {
+		return e.Text
+	}
+	var caret strings.Builder
+	for i, r := range []rune(e.Text) {
+		if i >= e.Column-1 {
+			break
+		}
+		// Keep tabs so the caret lines up with the text.
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	for i := len([]rune(e.Text)); i < e.Column-1; i++ {
+		caret.WriteRune(' ')
+	}
+	caret.WriteRune('^')
+	return e.Text + "\n" + caret.String()
+}
+
 const (
 	// A column with room for one character in it with padding on both sides.
 	minColumnWidth = 3
@@ -40,6 +112,51 @@ const (
 type ColumnSpec struct {
 	// Width of the column in number of characters (not counting the separators).
 	Width int
+	// Alignment of the text in the column.
+	Align Alignment
+}
+
+// Alignment is the horizontal alignment of the text in a column. Pandoc marks it with colons at the ends of the
+// column in the header separator line (or the top line, if the table has no header), e.g. "+:---+---:+:---:+".
+type Alignment int
+
+const (
+	AlignDefault Alignment = iota
+	AlignLeft
+	AlignRight
+	AlignCenter
+)
+
+// alignmentNames are the names of the alignments, with their abbreviations.
+var alignmentNames = []struct {
+	name, short string
+	align       Alignment
+}{
+	{"default", "d", AlignDefault},
+	{"left", "l", AlignLeft},
+	{"right", "r", AlignRight},
+	{"center", "c", AlignCenter},
+}
+
+// ParseAlignment parses the name of an alignment ("default", "left", "right" or "center") or its first letter.
+func ParseAlignment(s string) (Alignment, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, a := range alignmentNames {
+		if s == a.name || s == a.short {
+			return a.align, nil
+		}
+	}
+	return AlignDefault, fmt.Errorf("%w: invalid alignment %q (must be default, left, right or center)", ErrInvalidColumnSpec, s)
+}
+
+// String returns the name of the alignment.
+func (a Alignment) String() string {
+	for _, n := range alignmentNames {
+		if a == n.align {
+			return n.name
+		}
+	}
+	return fmt.Sprintf("Alignment(%d)", int(a))
 }
 
 // Cell is the contents to write into the cell of a table.
@@ -158,13 +275,20 @@ func drawCellContents(array [][]rune, x int, y int, row, column int, cell *Cell,
 // readCellContents performs a rectangular full-height selection of the text in the range [start, end).
 // It trims all extraneous whitespace, but preserves double-newlines.
 func readCellContents(lines [][]rune, start int, end int) string {
-	var result strings.Builder
+	text := make([]string, len(lines))
 	for i := range lines {
-		line := strings.TrimSpace(string(lines[i][start:end]))
-		fmt.Fprintf(&result, "%v\n", line)
+		text[i] = string(lines[i][start:end])
+	}
+	return joinCellLines(text)
+}
+
+// joinCellLines joins the lines of text in a cell. It trims all extraneous whitespace, but preserves double-newlines.
+func joinCellLines(lines []string) string {
+	var result strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&result, "%v\n", strings.TrimSpace(line))
 	}
 	paragraphs := strings.Split(result.String(), "\n\n")
-	result.Reset()
 	for i := range paragraphs {
 		paragraphs[i] = strings.Join(strings.Fields(paragraphs[i]), " ")
 	}
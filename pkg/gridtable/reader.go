@@ -3,9 +3,9 @@ package gridtable
 import (
 	"bufio"
 	"errors"
-	"fmt"
 	"io"
 	"iter"
+	"slices"
 	"strings"
 )
 
@@ -14,7 +14,25 @@ type Reader struct {
 	scanner *bufio.Scanner
 	config  Config
 	numRows int
-	done    bool
+	// The number of lines read so far.
+	numLines int
+	done     bool
+	// Whether the Reader repairs malformed tables (see NewLenientReader), and what it has repaired.
+	lenient bool
+	repairs []Repair
+	// The cells that span rows and continue past the last separator line read, in order of their columns, and the
+	// rows read since there were none, which can't be returned until those cells are complete.
+	open    []*openCell
+	pending [][]*Cell
+}
+
+// An openCell is a cell whose text is still being read, because it spans rows.
+type openCell struct {
+	cell *Cell
+	// The first column of the cell.
+	column int
+	// The lines of text in the cell so far.
+	lines []string
 }
 
 // NewReader instantiates a new Reader that reads table rows from an underlying io.Reader.
@@ -22,16 +40,16 @@ func NewReader(r io.Reader) (*Reader, error) {
 	scanner := bufio.NewScanner(r)
 	// Go ahead and read in the top line to get things started
 	if !scanner.Scan() {
-		return nil, fmt.Errorf("%w: table needs to contain at least one line of text", ErrMalformedTable)
+		return nil, errorAt(1, 0, "", ErrMalformedTable, "table needs to contain at least one line of text")
 	}
 	topLine := scanner.Text()
-	cols, isHdr, err := validateSeparator(topLine)
+	cols, isHdr, err := validateSeparator(1, topLine)
 	if err != nil {
 		return nil, err
 	}
 	if isHdr {
 		// Not OK at this time.
-		return nil, fmt.Errorf("%w: table cannot begin with '=' symbols", ErrMalformedTable)
+		return nil, errorAt(1, 2, topLine, ErrMalformedTable, "table cannot begin with '=' symbols")
 	}
 
 	return &Reader{
@@ -40,29 +58,41 @@ func NewReader(r io.Reader) (*Reader, error) {
 		config: Config{
 			Columns: cols,
 		},
+		numLines: 1,
 	}, nil
 }
 
 // validateSeparator returns the column-spec array described by the horizontal separator, or an error if the line is not a separator.
-// Note that at this time, row-spans are not supported.
-func validateSeparator(line string) (cols []ColumnSpec, isHeader bool, err error) {
+// lineNum is the line number of the separator within the table, for error reporting.
+// Separators that only close some of the columns, because of row spans, are handled by partialSeparator.
+func validateSeparator(lineNum int, line string) (cols []ColumnSpec, isHeader bool, err error) {
 	headerDecided := false
 	isHdr := false
 	if len(line) == 0 {
-		return nil, false, fmt.Errorf("%w: line of table cannot be empty", ErrMalformedTable)
+		return nil, false, errorAt(lineNum, 0, line, ErrMalformedTable, "line of table cannot be empty")
 	}
-	if line[0] != '+' || line[len(line)-1] != '+' {
-		return nil, false, fmt.Errorf("%w: separator must start and end with '+'", ErrMalformedTable)
+	if line[0] != '+' {
+		return nil, false, errorAt(lineNum, 1, line, ErrMalformedTable, "separator must start and end with '+'")
 	}
-	line = line[1 : len(line)-1]
-	for i, col := range strings.Split(line, "+") {
+	if line[len(line)-1] != '+' {
+		return nil, false, errorAt(lineNum, len(line), line, ErrMalformedTable, "separator must start and end with '+'")
+	}
+	// x is the (0-based) position of the current column within the line.
+	x := 1
+	for i, col := range strings.Split(line[1:len(line)-1], "+") {
 		colWidth := len(col)
 		if colWidth < minColumnWidth {
-			return nil, false, fmt.Errorf("%w: column %v too narrow at %v characters wide", ErrMalformedTable, i, colWidth)
+			return nil, false, errorAt(lineNum, x+1, line, ErrMalformedTable, "column %v too narrow at %v characters wide", i, colWidth)
 		}
-		// Check for funny business. We expect every character in col to be a - or a = (and all the same)
-		for _, char := range col {
+		// Check for funny business. We expect every character in col to be a - or a = (and all the same), except for
+		// the colons that mark the alignment of the column.
+		left, right := false, false
+		for dx, char := range col {
 			switch {
+			case char == ':' && dx == 0:
+				left = true
+			case char == ':' && dx == colWidth-1:
+				right = true
 			case !headerDecided && char == '-':
 				// OK, and remember for next time.
 				headerDecided = true
@@ -76,106 +106,227 @@ func validateSeparator(line string) (cols []ColumnSpec, isHeader bool, err error
 			case headerDecided && isHdr && char == '=':
 				// OK
 			default:
-				return nil, false, fmt.Errorf("%w: unexpected character %q in separator line", ErrMalformedTable, char)
+				return nil, false, errorAt(lineNum, x+dx+1, line, ErrMalformedTable, "unexpected character %q in separator line", char)
 			}
 		}
-		cols = append(cols, ColumnSpec{Width: len(col)})
+		spec := ColumnSpec{Width: len(col)}
+		switch {
+		case left && right:
+			spec.Align = AlignCenter
+		case left:
+			spec.Align = AlignLeft
+		case right:
+			spec.Align = AlignRight
+		}
+		cols = append(cols, spec)
+		x += colWidth + 1
 	}
 	if len(cols) == 0 {
-		return nil, false, fmt.Errorf("%w: table needs to contain at least one column", ErrMalformedTable)
+		return nil, false, errorAt(lineNum, 0, line, ErrMalformedTable, "table needs to contain at least one column")
 	}
 	return cols, isHdr, nil
 }
 
+// partialSeparator returns which columns are closed by a separator line that runs through cells that span rows, e.g.
+// "+---+   +" closes the first column but not the second. It returns nil if the line isn't such a separator (including
+// if it closes every column).
+func partialSeparator(config Config, line []rune) []bool {
+	expectedLineLen := 1
+	for _, col := range config.Columns {
+		expectedLineLen += col.Width + 1
+	}
+	if len(line) != expectedLineLen || !strings.ContainsRune("+|", line[0]) || !strings.ContainsRune("+|", line[len(line)-1]) {
+		return nil
+	}
+	closed := make([]bool, len(config.Columns))
+	numClosed := 0
+	x := 0
+	for j, col := range config.Columns {
+		segment := string(line[x+1 : x+1+col.Width])
+		switch {
+		case strings.Trim(segment, "-") == "":
+			// The dashes under a cell need '+'s on both sides.
+			if line[x] != '+' || line[x+col.Width+1] != '+' {
+				return nil
+			}
+			closed[j] = true
+			numClosed++
+		case strings.Trim(segment, "-=+:") == "":
+			// This is a malformed separator rather than the text of a cell.
+			return nil
+		}
+		x += col.Width + 1
+	}
+	// A line that starts with '+' can't be text, so it may run through cells that span rows without closing any.
+	if (numClosed == 0 && line[0] != '+') || numClosed == len(closed) {
+		return nil
+	}
+	return closed
+}
+
 // scanToNextSeparator reads to the next horizontal separator, returning the raw content in between and an indicator
-// of whether the header separator was encountered. It validates that the separator it found agrees with the passed-in
-// Config.
-func scanToNextSeparator(config Config, scanner *bufio.Scanner) (rawContents [][]rune, isHeader bool, err error) {
+// of whether the header separator was encountered. It validates that the separator it found agrees with the Reader's
+// Config. If the separator runs through cells that span rows, it is returned as partial.
+func (r *Reader) scanToNextSeparator() (rawContents [][]rune, isHeader bool, partial []rune, err error) {
 	var result [][]rune
 
-	for scanner.Scan() {
-		cols, isHdr, err := validateSeparator(scanner.Text())
+	for r.scanner.Scan() {
+		r.numLines++
+		line := r.scanner.Text()
+		cols, isHdr, err := validateSeparator(r.numLines, line)
 		if err != nil {
+			if !r.lenient && partialSeparator(r.config, []rune(line)) != nil {
+				return result, false, []rune(line), nil
+			}
 			// Assume it's jut not a separator.
-			result = append(result, []rune(scanner.Text()))
+			result = append(result, []rune(line))
 			continue
 		}
-		// Found a separator. Check that the columns agree.
-		if len(cols) != len(config.Columns) {
-			return nil, false, fmt.Errorf("%w: number of columns appeared to change midway through this table", ErrMalformedTable)
+		// Found a separator. The header separator gives the alignment of the columns.
+		if isHdr && len(cols) == len(r.config.Columns) {
+			for i := range cols {
+				r.config.Columns[i].Align = cols[i].Align
+			}
+		}
+		// Check that the columns agree.
+		if r.lenient && len(cols) == len(r.config.Columns) && !sameWidths(cols, r.config.Columns) {
+			r.repair(r.numLines, "fixed the column widths of the separator line")
+			return result, isHdr, nil, nil
 		}
+		x := 1
 		for i, col := range cols {
-			if col.Width != config.Columns[i].Width {
-				return nil, false, fmt.Errorf("%w: width of column %v appeared to change midway through this table", ErrMalformedTable, i)
+			if i >= len(r.config.Columns) {
+				return nil, false, nil, errorAt(r.numLines, x, line, ErrMalformedTable, "number of columns appeared to change midway through this table")
+			}
+			if col.Width != r.config.Columns[i].Width {
+				return nil, false, nil, errorAt(r.numLines, x+1+min(col.Width, r.config.Columns[i].Width), line, ErrMalformedTable, "width of column %v appeared to change midway through this table", i)
 			}
+			x += col.Width + 1
 		}
-		return result, isHdr, nil
+		if len(cols) != len(r.config.Columns) {
+			return nil, false, nil, errorAt(r.numLines, x, line, ErrMalformedTable, "number of columns appeared to change midway through this table")
+		}
+		return result, isHdr, nil, nil
 	}
 	// Special case: the table string might contain an empty line. If so, just return io.EOF and stop scanning.
-	if len(scanner.Text()) == 0 {
-		return nil, false, io.EOF
+	if len(r.scanner.Text()) == 0 {
+		return nil, false, nil, io.EOF
 	}
-	return nil, false, fmt.Errorf("%w: found content past the end of the table", ErrMalformedTable)
+	return nil, false, nil, errorAt(r.numLines, 0, r.scanner.Text(), ErrMalformedTable, "found content past the end of the table")
+}
+
+// sameWidths returns whether two sets of columns have the same widths.
+func sameWidths(a, b []ColumnSpec) bool {
+	return slices.EqualFunc(a, b, func(a, b ColumnSpec) bool {
+		return a.Width == b.Width
+	})
 }
 
 // cellsFromContent converts raw content into an array of cells. It uses the column configuration to determine if there
-// are any column spans. Shadowed cells are represented in the array as nil values.
-// Note that row spans are not supported at this time.
-func cellsFromContent(config Config, lines [][]rune) ([]*Cell, error) {
+// are any column spans, and the cells still open from the rows above to determine the row spans. Shadowed cells are
+// represented in the array as nil values. The text of a cell that continues past partial, the separator line below the
+// content (if it doesn't close every column), is only complete once the cell is closed.
+// firstLine is the line number of the first line of content within the table, for error reporting.
+func (r *Reader) cellsFromContent(lines [][]rune, firstLine int, partial []rune) ([]*Cell, error) {
+	config := r.config
 	// Basic validation
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("%w: each row needs to have at least one line of text", ErrMalformedTable)
+		return nil, errorAt(firstLine, 0, "", ErrMalformedTable, "each row needs to have at least one line of text")
 	}
 	expectedLineLen := 1
 	for _, col := range config.Columns {
 		expectedLineLen += col.Width + 1
 	}
-	for _, line := range lines {
+	for i, line := range lines {
 		if len(line) != expectedLineLen {
-			return nil, fmt.Errorf("%w: each line of text needs to have the same width", ErrMalformedTable)
+			return nil, errorAt(firstLine+i, min(len(line), expectedLineLen)+1, string(line), ErrMalformedTable, "each line of text needs to have the same width (expected %d characters, got %d)", expectedLineLen, len(line))
 		}
-		if line[0] != '|' || line[len(line)-1] != '|' {
-			return nil, fmt.Errorf("%w: each line of text needs to begin and end with a '|'", ErrMalformedTable)
+		if line[0] != '|' {
+			return nil, errorAt(firstLine+i, 1, string(line), ErrMalformedTable, "each line of text needs to begin and end with a '|'")
 		}
+		if line[len(line)-1] != '|' {
+			return nil, errorAt(firstLine+i, len(line), string(line), ErrMalformedTable, "each line of text needs to begin and end with a '|'")
+		}
+	}
+	// edges[j] is the position of the left edge of column j.
+	edges := make([]int, len(config.Columns)+1)
+	for j, col := range config.Columns {
+		edges[j+1] = edges[j] + col.Width + 1
 	}
+	// Find the cells in the row: the cells continuing from the rows above, and new cells in the other columns, which
+	// span up to the next '|'.
 	result := make([]*Cell, len(config.Columns))
-	// Check for spans and initialize the result array
-	currentCellIndex := 0
-	span := 0
-	dx := 0
-	for _, col := range config.Columns {
-		dx += 1 + col.Width
-		if lines[0][dx] == '|' {
-			// Found the right edge of whatever the current cell is.
-			// That means we can initialize the current Cell.
-			result[currentCellIndex] = &Cell{
-				ColSpan: span,
+	var cells []*openCell
+	open := r.open
+	for j := 0; j < len(config.Columns); {
+		if len(open) != 0 && open[0].column == j {
+			cells = append(cells, open[0])
+			j += open[0].cell.ColSpan + 1
+			open = open[1:]
+			if lines[0][edges[j]] != '|' {
+				return nil, errorAt(firstLine, edges[j]+1, string(lines[0]), ErrMalformedTable, "a cell can't span into a cell that spans rows")
 			}
-			currentCellIndex += 1 + span
-			span = 0
-		} else {
-			// Current cell spans. Keep going.
-			span++
+			continue
+		}
+		cell := &openCell{cell: &Cell{}, column: j}
+		for next := j + 1; next < len(config.Columns) && lines[0][edges[next]] != '|'; next++ {
+			if len(open) != 0 && open[0].column == next {
+				return nil, errorAt(firstLine, edges[next]+1, string(lines[0]), ErrMalformedTable, "a cell can't span into a cell that spans rows")
+			}
+			cell.cell.ColSpan++
 		}
+		result[j] = cell.cell
+		cells = append(cells, cell)
+		j += cell.cell.ColSpan + 1
 	}
-	// Populate the cells' text based on the raw lines
-	x := 1
-	for i, cell := range result {
-		if cell == nil {
+	// Collect the text of the cells, and close the cells that the separator closes.
+	closed := partialSeparator(config, partial)
+	r.open = nil
+	for _, c := range cells {
+		first, last := c.column, c.column+c.cell.ColSpan
+		start, end := edges[first]+1, edges[last+1]
+		for _, line := range lines {
+			c.lines = append(c.lines, string(line[start:end]))
+		}
+		if closed == nil || !slices.Contains(closed[first:last+1], false) {
+			c.cell.Text = joinCellLines(c.lines)
 			continue
 		}
-		dx := 0
-		for j := i; j <= i+cell.ColSpan; j++ {
-			dx += config.Columns[j].Width
+		if slices.Contains(closed[first:last+1], true) {
+			return nil, errorAt(firstLine+len(lines), start+1, string(partial), ErrMalformedTable, "separator line only closes part of a cell")
 		}
-		// Don't forget the additional space made by the missing '|'s, for spanning cells.
-		dx += cell.ColSpan
-		cell.Text = readCellContents(lines, x, x+dx)
-		x += dx + 1
+		// The cell continues into the next row, including the part of the separator line that it covers.
+		c.cell.RowSpan++
+		c.lines = append(c.lines, string(partial[start:end]))
+		r.open = append(r.open, c)
 	}
 	return result, nil
 }
 
+// lenientCellsFromContent is like cellsFromContent, but for a lenient Reader.
+func (r *Reader) lenientCellsFromContent(lines [][]rune, firstLine int) ([]*Cell, error) {
+	if len(lines) == 0 {
+		return nil, errorAt(firstLine, 0, "", ErrMalformedTable, "each row needs to have at least one line of text")
+	}
+	cells, cellLines, err := r.lenientCellLines(lines, firstLine)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for _, cell := range cells {
+		if cell == nil {
+			continue
+		}
+		var text []string
+		for _, line := range cellLines {
+			text = append(text, line[n])
+		}
+		cell.Text = joinCellLines(text)
+		n++
+	}
+	return cells, nil
+}
+
 // Read() returns an iterator over rows that can be ranged over using the range function.
 // A nil entry in the result indicates a shadowed cell.
 // It returns EndOfTable, nil when there are no more rows to read.
@@ -187,10 +338,15 @@ func (r *Reader) Read() iter.Seq2[[]*Cell, error] {
 		}
 		for {
 			// Look for the next separator.
-			content, isHdr, err := scanToNextSeparator(r.config, r.scanner)
+			firstLine := r.numLines + 1
+			content, isHdr, partial, err := r.scanToNextSeparator()
 			//
 			// Check for EOF and signal if needed.
 			if errors.Is(err, io.EOF) {
+				if len(r.open) != 0 {
+					yield(nil, errorAt(r.numLines, 0, "", ErrMalformedTable, "table ends inside a cell that spans rows"))
+					return
+				}
 				r.done = true
 				return
 			}
@@ -200,7 +356,12 @@ func (r *Reader) Read() iter.Seq2[[]*Cell, error] {
 				return
 			}
 			// Convert the content to cells and check for errors.
-			cells, err := cellsFromContent(r.config, content)
+			var cells []*Cell
+			if r.lenient {
+				cells, err = r.lenientCellsFromContent(content, firstLine)
+			} else {
+				cells, err = r.cellsFromContent(content, firstLine, partial)
+			}
 			if err != nil {
 				yield(nil, err)
 				return
@@ -209,15 +370,23 @@ func (r *Reader) Read() iter.Seq2[[]*Cell, error] {
 			if isHdr {
 				// Check if we've already seen a header.
 				if r.config.NumHeaderRows != 0 {
-					yield(nil, fmt.Errorf("%w: table cannot have two header separator rows", ErrMalformedTable))
+					yield(nil, errorAt(r.numLines, 0, r.scanner.Text(), ErrMalformedTable, "table cannot have two header separator rows"))
 					return
 				}
 				r.config.NumHeaderRows = r.numRows
 			}
-			// Yield the current row of cells.
-			if !yield(cells, nil) {
-				return
+			// Yield the rows read so far, unless some of their cells continue into the next row.
+			r.pending = append(r.pending, cells)
+			if len(r.open) != 0 {
+				continue
+			}
+			for _, row := range r.pending {
+				if !yield(row, nil) {
+					r.pending = nil
+					return
+				}
 			}
+			r.pending = nil
 		}
 	}
 }
@@ -39,6 +39,55 @@ func TestReadTable(t *testing.T) {
 				},
 			},
 		},
+		{
+			str: `+---+----+
+| A | B  |
++:==+:==:+
+| C | D  |
++---+----+
+`,
+			want: [][]*Cell{
+				{
+					{Text: "A"},
+					{Text: "B"},
+				},
+				{
+					{Text: "C"},
+					{Text: "D"},
+				},
+			},
+			wantConfig: Config{
+				NumHeaderRows: 1,
+				Columns: []ColumnSpec{
+					{Width: 3, Align: AlignLeft},
+					{Width: 4, Align: AlignCenter},
+				},
+			},
+		},
+		{
+			str: `+--:+---+
+| A | B |
++---+---+
+| C | D |
++---+---+
+`,
+			want: [][]*Cell{
+				{
+					{Text: "A"},
+					{Text: "B"},
+				},
+				{
+					{Text: "C"},
+					{Text: "D"},
+				},
+			},
+			wantConfig: Config{
+				Columns: []ColumnSpec{
+					{Width: 3, Align: AlignRight},
+					{Width: 3},
+				},
+			},
+		},
 		{
 			str: `+---+---+
 | A | B |
@@ -274,6 +323,102 @@ func TestReadTable(t *testing.T) {
 	}
 }
 
+func TestReadRowSpans(t *testing.T) {
+	for i, tc := range []struct {
+		str  string
+		want [][]*Cell
+	}{
+		{
+			str: `+---+---+
+| A | B |
++   +---+
+|   | D |
++---+---+
+`,
+			want: [][]*Cell{
+				{{Text: "A", RowSpan: 1}, {Text: "B"}},
+				{nil, {Text: "D"}},
+			},
+		},
+		{
+			// Pandoc's style, with '|' beside a cell that spans rows.
+			str: `+---+---+
+| A | B |
++---+   |
+| C |   |
++---+---+
+`,
+			want: [][]*Cell{
+				{{Text: "A"}, {Text: "B", RowSpan: 1}},
+				{{Text: "C"}, nil},
+			},
+		},
+		{
+			// The text of a cell continues across the separator lines it spans.
+			str: `+-------+---+
+| one   | B |
++ two   +---+
+| three | D |
++ four  +---+
+| five  | F |
++-------+---+
+| G     | H |
++-------+---+
+`,
+			want: [][]*Cell{
+				{{Text: "one two three four five", RowSpan: 2}, {Text: "B"}},
+				{nil, {Text: "D"}},
+				{nil, {Text: "F"}},
+				{{Text: "G"}, {Text: "H"}},
+			},
+		},
+		{
+			str: `+---+---+---+
+| A         |
++           +
+|           |
++---+---+---+
+| D | E | F |
++---+---+---+
+`,
+			want: [][]*Cell{
+				{{Text: "A", RowSpan: 1, ColSpan: 2}, nil, nil},
+				{nil, nil, nil},
+				{{Text: "D"}, {Text: "E"}, {Text: "F"}},
+			},
+		},
+		{
+			str: `+---+---+---+
+| A | B     |
++   +---+---+
+|   | E | F |
++---+---+---+
+`,
+			want: [][]*Cell{
+				{{Text: "A", RowSpan: 1}, {Text: "B", ColSpan: 1}, nil},
+				{nil, {Text: "E"}, {Text: "F"}},
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("table_%v", i), func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader([]byte(tc.str)))
+			if err != nil {
+				t.Fatalf("NewReader() = %v", err)
+			}
+			var got [][]*Cell
+			for cells, err := range r.Read() {
+				if err != nil {
+					t.Fatalf("Read() = %v", err)
+				}
+				got = append(got, cells)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Read() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
 func TestReadMalformedTable(t *testing.T) {
 	for i, tc := range []string{
 		`+---+!--+
@@ -305,6 +450,24 @@ func TestReadMalformedTable(t *testing.T) {
 +   +---+
 |   | D |
 +---+----+
+`,
+		`+---+---+---+
+| A     | C |
++---+   +---+
+| D |   | F |
++---+---+---+
+`,
+		`+---+---+
+| A | B |
++   +---+
+|   | D |
++   +---+
+`,
+		`+---+---+
+| A | B |
++   +---+
+|   C   |
++---+---+
 `,
 		`+===+===+
 | A | B |
@@ -334,3 +497,70 @@ func TestReadMalformedTable(t *testing.T) {
 		})
 	}
 }
+
+func TestReadMalformedTablePosition(t *testing.T) {
+	for i, tc := range []struct {
+		str         string
+		wantLine    int
+		wantColumn  int
+		wantSnippet string
+	}{
+		{
+			str: `+---+!--+
+| A | B |
++---+---+
+`,
+			wantLine:   1,
+			wantColumn: 6,
+			wantSnippet: `+---+!--+
+     ^`,
+		},
+		{
+			str: `+---+---+
+| A | B |
+| A | B
++---+---+
+`,
+			wantLine:   3,
+			wantColumn: 8,
+			wantSnippet: `| A | B
+       ^`,
+		},
+		{
+			str: `+---+---+
+| A | B |
++---+---+
+| C | D |
++---+----+
+`,
+			wantLine:   5,
+			wantColumn: 9,
+			wantSnippet: `+---+----+
+        ^`,
+		},
+	} {
+		t.Run(fmt.Sprintf("table_%v", i), func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader([]byte(tc.str)))
+			if err == nil {
+				for _, err = range r.Read() {
+					if err != nil {
+						break
+					}
+				}
+			}
+			var tableErr *TableError
+			if !errors.As(err, &tableErr) {
+				t.Fatalf("got %v want a *TableError", err)
+			}
+			if !errors.Is(err, ErrMalformedTable) {
+				t.Errorf("got %v want %v", err, ErrMalformedTable)
+			}
+			if tableErr.Line != tc.wantLine || tableErr.Column != tc.wantColumn {
+				t.Errorf("got line %v, column %v want line %v, column %v", tableErr.Line, tableErr.Column, tc.wantLine, tc.wantColumn)
+			}
+			if diff := cmp.Diff(tc.wantSnippet, tableErr.Snippet()); diff != "" {
+				t.Errorf("Snippet() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package gridtable
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// tabStop is the width of a tab stop, as in Pandoc.
+const tabStop = 4
+
+// A Repair describes something that a lenient Reader fixed while reading a table.
+type Repair struct {
+	// The (1-based) line number within the table.
+	Line int
+	// What was fixed.
+	Description string
+	// Whether the line was only the wrong width (e.g., it had trailing whitespace or a ragged right border), so that
+	// the fix didn't change the column layout or what the line says.
+	LineWidth bool
+}
+
+// String implements Stringer.
+func (r Repair) String() string {
+	return fmt.Sprintf("line %d: %v", r.Line, r.Description)
+}
+
+// NewLenientReader instantiates a Reader that tolerates the most common mistakes made when editing grid tables by
+// hand: lines that are too short or too long, missing trailing '|'s, trailing whitespace and tabs.
+// The column boundaries are taken from the majority of the separator lines. Use Repairs to find out what was fixed.
+func NewLenientReader(r io.Reader) (*Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var repairs []Repair
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+	for i, line := range lines {
+		if strings.ContainsRune(line, '\t') {
+			line = expandTabs(line)
+			repairs = append(repairs, Repair{Line: i + 1, Description: "expanded tabs"})
+		}
+		if trimmed := strings.TrimRight(line, " "); trimmed != line {
+			line = trimmed
+			repairs = append(repairs, Repair{Line: i + 1, Description: "removed trailing whitespace", LineWidth: true})
+		}
+		lines[i] = line
+	}
+
+	// Use the most common column layout among the separator lines.
+	counts := make(map[string]int)
+	var layouts []string
+	for i, line := range lines {
+		if cols, _, err := validateSeparator(i+1, line); err == nil {
+			layout := fmt.Sprint(cols)
+			if counts[layout] == 0 {
+				layouts = append(layouts, layout)
+			}
+			counts[layout]++
+		}
+	}
+	best := ""
+	for _, layout := range layouts {
+		if counts[layout] > counts[best] {
+			best = layout
+		}
+	}
+
+	reader, err := NewReader(strings.NewReader(strings.Join(lines, "\n") + "\n"))
+	if err != nil {
+		return nil, err
+	}
+	reader.lenient = true
+	reader.repairs = repairs
+	if best != "" && fmt.Sprint(reader.config.Columns) != best {
+		cols, _, _ := validateSeparator(1, firstSeparatorWithLayout(lines, best))
+		if len(cols) != len(reader.config.Columns) {
+			return nil, errorAt(1, 0, lines[0], ErrMalformedTable, "number of columns appeared to change midway through this table")
+		}
+		reader.config.Columns = cols
+		reader.repair(1, "fixed the column widths of the separator line")
+	}
+	return reader, nil
+}
+
+// firstSeparatorWithLayout returns the first separator line with the given column layout.
+func firstSeparatorWithLayout(lines []string, layout string) string {
+	for i, line := range lines {
+		if cols, _, err := validateSeparator(i+1, line); err == nil && fmt.Sprint(cols) == layout {
+			return line
+		}
+	}
+	return ""
+}
+
+// expandTabs replaces the tabs in a line with spaces up to the next tab stop.
+func expandTabs(line string) string {
+	var sb strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			for {
+				sb.WriteRune(' ')
+				col++
+				if col%tabStop == 0 {
+					break
+				}
+			}
+			continue
+		}
+		sb.WriteRune(r)
+		col++
+	}
+	return sb.String()
+}
+
+// Repairs returns what a lenient Reader has fixed so far, in order of line number.
+func (r *Reader) Repairs() []Repair {
+	result := slices.Clone(r.repairs)
+	slices.SortStableFunc(result, func(a, b Repair) int {
+		return a.Line - b.Line
+	})
+	return result
+}
+
+func (r *Reader) repair(line int, format string, args ...any) {
+	r.repairs = append(r.repairs, Repair{Line: line, Description: fmt.Sprintf(format, args...)})
+}
+
+// repairLineWidth is like repair, but for a line that was only the wrong width.
+func (r *Reader) repairLineWidth(line int, format string, args ...any) {
+	r.repairs = append(r.repairs, Repair{Line: line, Description: fmt.Sprintf(format, args...), LineWidth: true})
+}
+
+// lenientCellLines splits the lines of text in a row into the text of each cell on each line, for a lenient Reader.
+// Lines with the expected layout are split at the column boundaries, like cellsFromContent does. Other lines are split
+// at their '|'s, as long as they have the same number of cells as the row's well-formed lines.
+// It returns the cells of the row (without text) and lines[i][j] = the text of the j'th cell on the i'th line.
+func (r *Reader) lenientCellLines(lines [][]rune, firstLine int) ([]*Cell, [][]string, error) {
+	expectedLineLen := calculateTableWidth(r.config.Columns)
+	hasBorders := func(line []rune) bool {
+		return len(line) == expectedLineLen && line[0] == '|' && line[len(line)-1] == '|'
+	}
+	// Work out the spans from the first line with the right borders. If there isn't one, assume there are no spans.
+	var pattern []rune
+	for _, line := range lines {
+		if hasBorders(line) {
+			pattern = line
+			break
+		}
+	}
+	cells := make([]*Cell, len(r.config.Columns))
+	var bounds [][2]int
+	x := 1
+	start, current, span := 1, 0, 0
+	for _, col := range r.config.Columns {
+		x += col.Width
+		if pattern == nil || pattern[x] == '|' {
+			cells[current] = &Cell{ColSpan: span}
+			bounds = append(bounds, [2]int{start, x})
+			current += 1 + span
+			start, span = x+1, 0
+		} else {
+			span++
+		}
+		x++
+	}
+	wellFormed := func(line []rune) bool {
+		if !hasBorders(line) {
+			return false
+		}
+		for _, b := range bounds {
+			if line[b[1]] != '|' {
+				return false
+			}
+		}
+		return true
+	}
+
+	result := make([][]string, len(lines))
+	for i, line := range lines {
+		if wellFormed(line) {
+			for _, b := range bounds {
+				result[i] = append(result[i], string(line[b[0]:b[1]]))
+			}
+			continue
+		}
+		text := string(line)
+		if !strings.HasPrefix(text, "|") {
+			return nil, nil, errorAt(firstLine+i, 1, text, ErrMalformedTable, "each line of text needs to begin and end with a '|'")
+		}
+		text = text[1:]
+		if strings.HasSuffix(text, "|") {
+			text = text[:len(text)-1]
+		} else {
+			r.repairLineWidth(firstLine+i, "added the missing '|' at the end of the line")
+		}
+		segments := strings.Split(text, "|")
+		if len(segments) != len(bounds) {
+			return nil, nil, errorAt(firstLine+i, 0, string(line), ErrMalformedTable, "line has %d cells, expected %d", len(segments), len(bounds))
+		}
+		if len(line) != expectedLineLen {
+			r.repairLineWidth(firstLine+i, "fixed the length of the line (expected %d characters, got %d)", expectedLineLen, len(line))
+		} else {
+			r.repair(firstLine+i, "moved the '|'s back to the column boundaries")
+		}
+		result[i] = segments
+	}
+	return cells, result, nil
+}
@@ -0,0 +1,129 @@
+package gridtable
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLenientReader(t *testing.T) {
+	for i, tc := range []struct {
+		str         string
+		want        [][]*Cell
+		wantColumns []ColumnSpec
+		wantRepairs []Repair
+	}{
+		{
+			// Already well-formed.
+			str: `+---+---+
+| A | B |
++===+===+
+| C | D |
++---+---+
+`,
+			want:        [][]*Cell{{{Text: "A"}, {Text: "B"}}, {{Text: "C"}, {Text: "D"}}},
+			wantColumns: []ColumnSpec{{Width: 3}, {Width: 3}},
+		},
+		{
+			// Short and long lines, a missing '|', trailing whitespace and a tab.
+			str: "+------+-----+\n" +
+				"| Name | Age |  \n" +
+				"+======+=====+\n" +
+				"| Bob | 42 |\n" +
+				"| Smith   | |\n" +
+				"+------+-----+\n" +
+				"|\tAl  | 7\n" +
+				"+------+-----+\n",
+			want: [][]*Cell{
+				{{Text: "Name"}, {Text: "Age"}},
+				{{Text: "Bob Smith"}, {Text: "42"}},
+				{{Text: "Al"}, {Text: "7"}},
+			},
+			wantColumns: []ColumnSpec{{Width: 6}, {Width: 5}},
+			wantRepairs: []Repair{
+				{Line: 2, Description: "removed trailing whitespace", LineWidth: true},
+				{Line: 4, Description: "fixed the length of the line (expected 14 characters, got 12)", LineWidth: true},
+				{Line: 5, Description: "fixed the length of the line (expected 14 characters, got 13)", LineWidth: true},
+				{Line: 7, Description: "expanded tabs"},
+				{Line: 7, Description: "added the missing '|' at the end of the line", LineWidth: true},
+				{Line: 7, Description: "fixed the length of the line (expected 14 characters, got 11)", LineWidth: true},
+			},
+		},
+		{
+			// A separator that doesn't match the others.
+			str: `+---+----+
+| A | B  |
++---+-----+
+| C | D  |
++---+----+
+`,
+			want:        [][]*Cell{{{Text: "A"}, {Text: "B"}}, {{Text: "C"}, {Text: "D"}}},
+			wantColumns: []ColumnSpec{{Width: 3}, {Width: 4}},
+			wantRepairs: []Repair{
+				{Line: 3, Description: "fixed the column widths of the separator line"},
+			},
+		},
+		{
+			// A column span is kept, even if a line in the row is malformed.
+			str: `+---+---+
+| A spans |
+| two   |
++---+---+
+| C | D |
++---+---+
+`,
+			want:        [][]*Cell{{{Text: "A spans two", ColSpan: 1}, nil}, {{Text: "C"}, {Text: "D"}}},
+			wantColumns: []ColumnSpec{{Width: 3}, {Width: 3}},
+			wantRepairs: []Repair{
+				{Line: 2, Description: "fixed the length of the line (expected 9 characters, got 11)", LineWidth: true},
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("table_%v", i), func(t *testing.T) {
+			r, err := NewLenientReader(strings.NewReader(tc.str))
+			if err != nil {
+				t.Fatalf("NewLenientReader() = %v", err)
+			}
+			config, rows, err := readTable(r)
+			if err != nil {
+				t.Fatalf("readTable() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, rows); diff != "" {
+				t.Errorf("readTable() diff (-want +got)\n%v", diff)
+			}
+			if diff := cmp.Diff(tc.wantColumns, config.Columns); diff != "" {
+				t.Errorf("readTable() columns diff (-want +got)\n%v", diff)
+			}
+			if diff := cmp.Diff(tc.wantRepairs, r.Repairs()); diff != "" {
+				t.Errorf("Repairs() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestLenientReaderUnrepairable(t *testing.T) {
+	for i, tc := range []string{
+		// A cell is missing.
+		`+---+---+---+
+| A | B |
++---+---+---+
+`,
+		// A line doesn't start with '|'.
+		`+---+---+
+ A  | B |
++---+---+
+`,
+	} {
+		t.Run(fmt.Sprintf("table_%v", i), func(t *testing.T) {
+			r, err := NewLenientReader(strings.NewReader(tc))
+			if err != nil {
+				return
+			}
+			if _, rows, err := readTable(r); err == nil {
+				t.Errorf("readTable() = %v, want error", rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,487 @@
+package gridtable
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// A Table is an in-memory grid table that can be edited structurally.
+// Cells are addressed by their (0-based) row and column. A cell that spans covers the positions to its right and below
+// it; those positions are "shadowed" and have no cell of their own.
+type Table struct {
+	config Config
+	// cells[i][j] is the cell in the i'th row and j'th column, or nil if that position is shadowed.
+	cells [][]*Cell
+}
+
+// NewTable creates a table from its configuration and rows, e.g. as read by ReadTable. A nil entry in rows indicates a
+// shadowed cell. The cells are copied.
+func NewTable(config Config, rows [][]*Cell) (*Table, error) {
+	if len(config.Columns) == 0 {
+		return nil, fmt.Errorf("%w: table needs at least 1 column", ErrInvalidColumnSpec)
+	}
+	for j, col := range config.Columns {
+		if col.Width < minColumnWidth {
+			return nil, fmt.Errorf("%w: column %d has width %d (minimum: %d)", ErrInvalidColumnSpec, j, col.Width, minColumnWidth)
+		}
+	}
+	if config.NumHeaderRows < 0 || config.NumHeaderRows > len(rows) {
+		return nil, fmt.Errorf("%w: table has %d header rows but only %d rows", ErrSpanBeyondHeader, config.NumHeaderRows, len(rows))
+	}
+	t := &Table{
+		config: Config{
+			NumHeaderRows: config.NumHeaderRows,
+			Columns:       slices.Clone(config.Columns),
+		},
+	}
+	for i, row := range rows {
+		if len(row) != len(config.Columns) {
+			return nil, fmt.Errorf("%w: row %d has %d columns, expected %d", ErrColumnIndexOutOfRange, i, len(row), len(config.Columns))
+		}
+		t.cells = append(t.cells, make([]*Cell, len(row)))
+		for j, cell := range row {
+			if cell != nil {
+				c := *cell
+				t.cells[i][j] = &c
+			}
+		}
+	}
+	// Check that the spans exactly account for the shadowed positions.
+	covered := make([][]bool, len(rows))
+	for i := range covered {
+		covered[i] = make([]bool, len(config.Columns))
+	}
+	for i, row := range t.cells {
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			if err := t.checkSpan(i, j, cell.RowSpan, cell.ColSpan); err != nil {
+				return nil, err
+			}
+			for a := i; a <= i+cell.RowSpan; a++ {
+				for b := j; b <= j+cell.ColSpan; b++ {
+					if covered[a][b] {
+						return nil, fmt.Errorf("%w: at row %d, column %d", ErrOverlappingSpans, a, b)
+					}
+					covered[a][b] = true
+				}
+			}
+		}
+	}
+	for i, row := range covered {
+		for j, c := range row {
+			if !c {
+				return nil, fmt.Errorf("%w: position at row %d, column %d is neither a cell nor shadowed by one", ErrShadowedCell, i, j)
+			}
+		}
+	}
+	return t, nil
+}
+
+// ReadAll reads the rest of the table into a Table.
+func (r *Reader) ReadAll() (*Table, error) {
+	config, rows, err := readTable(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(*config, rows)
+}
+
+// checkSpan checks that a cell at row i, column j with the given spans would fit in the table.
+func (t *Table) checkSpan(i, j, rowSpan, colSpan int) error {
+	if rowSpan < 0 || colSpan < 0 {
+		return fmt.Errorf("%w: cell at row %d, column %d", ErrNegativeSpan, i, j)
+	}
+	if j+colSpan >= len(t.config.Columns) {
+		return fmt.Errorf("%w: cell at row %d, column %d spans %d columns, but the table has only %d", ErrColumnIndexOutOfRange, i, j, colSpan+1, len(t.config.Columns))
+	}
+	if i+rowSpan >= len(t.cells) {
+		return fmt.Errorf("%w: cell at row %d, column %d spans %d rows, but the table has only %d", ErrColumnIndexOutOfRange, i, j, rowSpan+1, len(t.cells))
+	}
+	if i < t.config.NumHeaderRows && i+rowSpan >= t.config.NumHeaderRows {
+		return fmt.Errorf("%w: cell at row %d, column %d spans %d rows, but the header is only %d rows", ErrSpanBeyondHeader, i, j, rowSpan+1, t.config.NumHeaderRows)
+	}
+	return nil
+}
+
+// Config returns the configuration of the table.
+func (t *Table) Config() Config {
+	return Config{
+		NumHeaderRows: t.config.NumHeaderRows,
+		Columns:       slices.Clone(t.config.Columns),
+	}
+}
+
+// Rows returns the cells of the table, with nil entries for shadowed positions. The cells are shared with the table.
+func (t *Table) Rows() [][]*Cell {
+	result := make([][]*Cell, len(t.cells))
+	for i, row := range t.cells {
+		result[i] = slices.Clone(row)
+	}
+	return result
+}
+
+// NumRows returns the number of rows in the table.
+func (t *Table) NumRows() int {
+	return len(t.cells)
+}
+
+// NumColumns returns the number of columns in the table.
+func (t *Table) NumColumns() int {
+	return len(t.config.Columns)
+}
+
+// SetNumHeaderRows sets the number of rows in the header.
+func (t *Table) SetNumHeaderRows(n int) error {
+	if n < 0 || n > len(t.cells) {
+		return fmt.Errorf("%w: table has %d rows", ErrSpanBeyondHeader, len(t.cells))
+	}
+	for a, b := range t.owners() {
+		cell := t.cells[a][b]
+		if a < n && a+cell.RowSpan >= n {
+			return fmt.Errorf("%w: cell at row %d, column %d spans %d rows", ErrSpanBeyondHeader, a, b, cell.RowSpan+1)
+		}
+	}
+	t.config.NumHeaderRows = n
+	return nil
+}
+
+// SetColumnWidth sets the width of column j.
+func (t *Table) SetColumnWidth(j int, width int) error {
+	if j < 0 || j >= len(t.config.Columns) {
+		return fmt.Errorf("%w: %d", ErrColumnIndexOutOfRange, j)
+	}
+	if width < minColumnWidth {
+		return fmt.Errorf("%w: column %d has width %d (minimum: %d)", ErrInvalidColumnSpec, j, width, minColumnWidth)
+	}
+	t.config.Columns[j].Width = width
+	return nil
+}
+
+// Cell returns the cell at row i, column j, or nil if the position is shadowed or out of range.
+// The cell's text may be modified directly, but its spans must only be changed with SetSpan.
+func (t *Table) Cell(i, j int) *Cell {
+	if i < 0 || i >= len(t.cells) || j < 0 || j >= len(t.config.Columns) {
+		return nil
+	}
+	return t.cells[i][j]
+}
+
+// Owner returns the position of the cell that covers row i, column j: either the cell at that position, or the
+// spanning cell that shadows it.
+func (t *Table) Owner(i, j int) (int, int, error) {
+	if i < 0 || i >= len(t.cells) || j < 0 || j >= len(t.config.Columns) {
+		return 0, 0, fmt.Errorf("%w: row %d, column %d", ErrColumnIndexOutOfRange, i, j)
+	}
+	for a, b := range t.owners() {
+		cell := t.cells[a][b]
+		if a <= i && i <= a+cell.RowSpan && b <= j && j <= b+cell.ColSpan {
+			return a, b, nil
+		}
+	}
+	// NewTable and the editing operations keep every position covered.
+	panic(fmt.Sprintf("no cell covers row %d, column %d", i, j))
+}
+
+// owners iterates over the positions of the (non-shadowed) cells.
+func (t *Table) owners() iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for i, row := range t.cells {
+			for j, cell := range row {
+				if cell != nil && !yield(i, j) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// SetSpan changes the spans of the cell at row i, column j. The spans count the additional rows and columns covered, as
+// in Cell. A cell can only grow over empty cells that don't span; positions it no longer covers become empty cells.
+func (t *Table) SetSpan(i, j, rowSpan, colSpan int) error {
+	cell := t.Cell(i, j)
+	if cell == nil {
+		return fmt.Errorf("%w: row %d, column %d is not a cell", ErrShadowedCell, i, j)
+	}
+	if err := t.checkSpan(i, j, rowSpan, colSpan); err != nil {
+		return err
+	}
+	inOld := func(a, b int) bool {
+		return a <= i+cell.RowSpan && b <= j+cell.ColSpan
+	}
+	inNew := func(a, b int) bool {
+		return a <= i+rowSpan && b <= j+colSpan
+	}
+	// Check the positions the cell will newly cover.
+	for a := i; a <= i+rowSpan; a++ {
+		for b := j; b <= j+colSpan; b++ {
+			if inOld(a, b) {
+				continue
+			}
+			other := t.cells[a][b]
+			if other == nil {
+				return fmt.Errorf("%w: cell at row %d, column %d would overlap a span at row %d, column %d", ErrOverlappingSpans, i, j, a, b)
+			}
+			if other.RowSpan != 0 || other.ColSpan != 0 || other.Text != "" {
+				return fmt.Errorf("%w: cell at row %d, column %d would cover the non-empty cell at row %d, column %d", ErrOverlappingSpans, i, j, a, b)
+			}
+		}
+	}
+	for a := i; a <= i+max(rowSpan, cell.RowSpan); a++ {
+		for b := j; b <= j+max(colSpan, cell.ColSpan); b++ {
+			if a == i && b == j {
+				continue
+			}
+			switch {
+			case inNew(a, b):
+				t.cells[a][b] = nil
+			case inOld(a, b):
+				t.cells[a][b] = &Cell{}
+			}
+		}
+	}
+	cell.RowSpan = rowSpan
+	cell.ColSpan = colSpan
+	return nil
+}
+
+// InsertRow inserts an empty row before row i (or at the end of the table, if i is NumRows). Cells that span across the
+// new row are extended to cover it. Inserting a row before the end of the header adds it to the header.
+func (t *Table) InsertRow(i int) error {
+	if i < 0 || i > len(t.cells) {
+		return fmt.Errorf("%w: row %d", ErrColumnIndexOutOfRange, i)
+	}
+	row := make([]*Cell, len(t.config.Columns))
+	for j := range row {
+		row[j] = &Cell{}
+	}
+	for a, b := range t.owners() {
+		cell := t.cells[a][b]
+		if a < i && i <= a+cell.RowSpan {
+			cell.RowSpan++
+			for dj := 0; dj <= cell.ColSpan; dj++ {
+				row[b+dj] = nil
+			}
+		}
+	}
+	t.cells = slices.Insert(t.cells, i, row)
+	if i < t.config.NumHeaderRows {
+		t.config.NumHeaderRows++
+	}
+	return nil
+}
+
+// DeleteRow deletes row i. Cells that span across it shrink, and the text of a cell in row i that spans below it moves
+// to the next row.
+func (t *Table) DeleteRow(i int) error {
+	if i < 0 || i >= len(t.cells) {
+		return fmt.Errorf("%w: row %d", ErrColumnIndexOutOfRange, i)
+	}
+	if len(t.cells) == 1 {
+		return fmt.Errorf("%w: table needs at least 1 row", ErrColumnIndexOutOfRange)
+	}
+	for a, b := range t.owners() {
+		cell := t.cells[a][b]
+		switch {
+		case a < i && i <= a+cell.RowSpan:
+			cell.RowSpan--
+		case a == i && cell.RowSpan > 0:
+			cell.RowSpan--
+			t.cells[i+1][b] = cell
+		}
+	}
+	t.cells = slices.Delete(t.cells, i, i+1)
+	if i < t.config.NumHeaderRows {
+		t.config.NumHeaderRows--
+	}
+	return nil
+}
+
+// InsertColumn inserts an empty column before column j (or at the end of the table, if j is NumColumns). Cells that
+// span across the new column are extended to cover it.
+func (t *Table) InsertColumn(j int, spec ColumnSpec) error {
+	if j < 0 || j > len(t.config.Columns) {
+		return fmt.Errorf("%w: %d", ErrColumnIndexOutOfRange, j)
+	}
+	if spec.Width < minColumnWidth {
+		return fmt.Errorf("%w: column %d has width %d (minimum: %d)", ErrInvalidColumnSpec, j, spec.Width, minColumnWidth)
+	}
+	column := make([]*Cell, len(t.cells))
+	for i := range column {
+		column[i] = &Cell{}
+	}
+	for a, b := range t.owners() {
+		cell := t.cells[a][b]
+		if b < j && j <= b+cell.ColSpan {
+			cell.ColSpan++
+			for di := 0; di <= cell.RowSpan; di++ {
+				column[a+di] = nil
+			}
+		}
+	}
+	for i := range t.cells {
+		t.cells[i] = slices.Insert(t.cells[i], j, column[i])
+	}
+	t.config.Columns = slices.Insert(t.config.Columns, j, spec)
+	return nil
+}
+
+// DeleteColumn deletes column j. Cells that span across it shrink, and the text of a cell in column j that spans to
+// the right of it moves to the next column.
+func (t *Table) DeleteColumn(j int) error {
+	if j < 0 || j >= len(t.config.Columns) {
+		return fmt.Errorf("%w: %d", ErrColumnIndexOutOfRange, j)
+	}
+	if len(t.config.Columns) == 1 {
+		return fmt.Errorf("%w: table needs at least 1 column", ErrInvalidColumnSpec)
+	}
+	for a, b := range t.owners() {
+		cell := t.cells[a][b]
+		switch {
+		case b < j && j <= b+cell.ColSpan:
+			cell.ColSpan--
+		case b == j && cell.ColSpan > 0:
+			cell.ColSpan--
+			t.cells[a][j+1] = cell
+		}
+	}
+	for i := range t.cells {
+		t.cells[i] = slices.Delete(t.cells[i], j, j+1)
+	}
+	t.config.Columns = slices.Delete(t.config.Columns, j, j+1)
+	return nil
+}
+
+// MoveColumn moves column from so that it becomes column to. Columns that are part of a column span can't be moved,
+// and a column can't be moved into the middle of a span.
+func (t *Table) MoveColumn(from, to int) error {
+	n := len(t.config.Columns)
+	if from < 0 || from >= n {
+		return fmt.Errorf("%w: %d", ErrColumnIndexOutOfRange, from)
+	}
+	if to < 0 || to >= n {
+		return fmt.Errorf("%w: %d", ErrColumnIndexOutOfRange, to)
+	}
+	if from == to {
+		return nil
+	}
+	for a, b := range t.owners() {
+		cell := t.cells[a][b]
+		if cell.ColSpan == 0 {
+			continue
+		}
+		if b <= from && from <= b+cell.ColSpan {
+			return fmt.Errorf("%w: column %d is part of the span of the cell at row %d, column %d", ErrOverlappingSpans, from, a, b)
+		}
+		// Moving the column left puts it before column to; moving it right puts it after column to.
+		if (from > to && b < to && to <= b+cell.ColSpan) || (from < to && b <= to && to < b+cell.ColSpan) {
+			return fmt.Errorf("%w: column %d would split the span of the cell at row %d, column %d", ErrOverlappingSpans, from, a, b)
+		}
+	}
+	spec := t.config.Columns[from]
+	t.config.Columns = slices.Insert(slices.Delete(t.config.Columns, from, from+1), to, spec)
+	for i, row := range t.cells {
+		cell := row[from]
+		t.cells[i] = slices.Insert(slices.Delete(row, from, from+1), to, cell)
+	}
+	return nil
+}
+
+// SortRows sorts the body rows of the table (the header rows stay where they are). Rows that are tied together by row
+// spans move as a group, and groups are ordered by comparing their first rows: cmp(a, b) compares the rows that were
+// at indexes a and b before sorting. The sort is stable.
+func (t *Table) SortRows(cmp func(a, b int) int) {
+	// Split the body into groups of rows, where each group ends at a row below which nothing spans.
+	var groups [][2]int
+	start, end := t.config.NumHeaderRows, t.config.NumHeaderRows
+	for i := t.config.NumHeaderRows; i < len(t.cells); i++ {
+		for _, cell := range t.cells[i] {
+			if cell != nil {
+				end = max(end, i+cell.RowSpan)
+			}
+		}
+		if end == i {
+			groups = append(groups, [2]int{start, i + 1})
+			start, end = i+1, i+1
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b [2]int) int {
+		return cmp(a[0], b[0])
+	})
+	sorted := slices.Clone(t.cells[:t.config.NumHeaderRows])
+	for _, g := range groups {
+		sorted = append(sorted, t.cells[g[0]:g[1]]...)
+	}
+	t.cells = sorted
+}
+
+// Transpose returns a copy of the table with its rows and columns swapped, so that column spans become row spans and
+// vice versa. The first numHeaderRows rows of the result (i.e., the first columns of the table) form its header. All of
+// the columns of the result are as narrow as possible; use FitColumns to choose their widths.
+func (t *Table) Transpose(numHeaderRows int) (*Table, error) {
+	config := Config{
+		NumHeaderRows: numHeaderRows,
+		Columns:       make([]ColumnSpec, len(t.cells)),
+	}
+	for j := range config.Columns {
+		config.Columns[j].Width = minColumnWidth
+	}
+	rows := make([][]*Cell, len(t.config.Columns))
+	for j := range rows {
+		rows[j] = make([]*Cell, len(t.cells))
+		for i, row := range t.cells {
+			if cell := row[j]; cell != nil {
+				rows[j][i] = &Cell{Text: cell.Text, RowSpan: cell.ColSpan, ColSpan: cell.RowSpan}
+			}
+		}
+	}
+	return NewTable(config, rows)
+}
+
+// FitColumns sets the widths of the columns according to the text in them (see FitWidths), so that the table is at
+// most tableWidth characters wide. Columns only grow until their text fits without wrapping, so the table is narrower
+// than tableWidth if it doesn't need the space. Cells that span several columns are not taken into account.
+func (t *Table) FitColumns(tableWidth int) {
+	natural := make([]int, len(t.config.Columns))
+	minimum := make([]int, len(t.config.Columns))
+	for a, b := range t.owners() {
+		cell := t.cells[a][b]
+		if cell.ColSpan != 0 {
+			continue
+		}
+		for _, line := range strings.Split(cell.Text, "\n") {
+			natural[b] = max(natural[b], len([]rune(line))+2)
+		}
+		for _, word := range strings.Fields(cell.Text) {
+			minimum[b] = max(minimum[b], len([]rune(word))+2)
+		}
+	}
+	for j, width := range FitWidths(tableWidth-len(t.config.Columns)-1, natural, minimum) {
+		t.config.Columns[j].Width = width
+	}
+}
+
+// String renders the table.
+func (t *Table) String() (string, error) {
+	w, err := NewWriter(t.Config())
+	if err != nil {
+		return "", err
+	}
+	for i, row := range t.cells {
+		if i != 0 {
+			w.NextRow()
+		}
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			if err := w.WriteColumn(j, *cell); err != nil {
+				return "", err
+			}
+		}
+	}
+	return w.String()
+}
@@ -0,0 +1,321 @@
+package gridtable
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTableEdits(t *testing.T) {
+	table := `+---+---+---+
+| A | B | C |
++===+===+===+
+| D     | E |
++---+---+---+
+| F | G | H |
++---+---+---+
+`
+	for _, tc := range []struct {
+		name    string
+		edit    func(*Table) error
+		want    string
+		wantErr error
+	}{
+		{
+			name: "insert row in body",
+			edit: func(t *Table) error { return t.InsertRow(2) },
+			want: `+---+---+---+
+| A | B | C |
++===+===+===+
+| D     | E |
++---+---+---+
+|   |   |   |
++---+---+---+
+| F | G | H |
++---+---+---+
+`,
+		},
+		{
+			name: "insert row in header",
+			edit: func(t *Table) error { return t.InsertRow(0) },
+			want: `+---+---+---+
+|   |   |   |
++---+---+---+
+| A | B | C |
++===+===+===+
+| D     | E |
++---+---+---+
+| F | G | H |
++---+---+---+
+`,
+		},
+		{
+			name: "delete row",
+			edit: func(t *Table) error { return t.DeleteRow(1) },
+			want: `+---+---+---+
+| A | B | C |
++===+===+===+
+| F | G | H |
++---+---+---+
+`,
+		},
+		{
+			name: "insert column inside span",
+			edit: func(t *Table) error { return t.InsertColumn(1, ColumnSpec{Width: 5}) },
+			want: `+---+-----+---+---+
+| A |     | B | C |
++===+=====+===+===+
+| D           | E |
++---+-----+---+---+
+| F |     | G | H |
++---+-----+---+---+
+`,
+		},
+		{
+			name: "delete first column of span",
+			edit: func(t *Table) error { return t.DeleteColumn(0) },
+			want: `+---+---+
+| B | C |
++===+===+
+| D | E |
++---+---+
+| G | H |
++---+---+
+`,
+		},
+		{
+			name: "move column",
+			edit: func(t *Table) error { return t.MoveColumn(2, 0) },
+			want: `+---+---+---+
+| C | A | B |
++===+===+===+
+| E | D     |
++---+---+---+
+| H | F | G |
++---+---+---+
+`,
+		},
+		{
+			name:    "move spanned column",
+			edit:    func(t *Table) error { return t.MoveColumn(0, 2) },
+			wantErr: ErrOverlappingSpans,
+		},
+		{
+			name:    "move column into span",
+			edit:    func(t *Table) error { return t.MoveColumn(2, 1) },
+			wantErr: ErrOverlappingSpans,
+		},
+		{
+			name: "remove span",
+			edit: func(t *Table) error { return t.SetSpan(1, 0, 0, 0) },
+			want: `+---+---+---+
+| A | B | C |
++===+===+===+
+| D |   | E |
++---+---+---+
+| F | G | H |
++---+---+---+
+`,
+		},
+		{
+			name: "span over empty cell",
+			edit: func(t *Table) error {
+				t.Cell(0, 1).Text = ""
+				return t.SetSpan(0, 0, 0, 1)
+			},
+			want: `+---+---+---+
+| A     | C |
++===+===+===+
+| D     | E |
++---+---+---+
+| F | G | H |
++---+---+---+
+`,
+		},
+		{
+			name:    "span over non-empty cell",
+			edit:    func(t *Table) error { return t.SetSpan(0, 0, 0, 1) },
+			wantErr: ErrOverlappingSpans,
+		},
+		{
+			name:    "span beyond header",
+			edit:    func(t *Table) error { return t.SetSpan(0, 2, 1, 0) },
+			wantErr: ErrSpanBeyondHeader,
+		},
+		{
+			name:    "span shadowed cell",
+			edit:    func(t *Table) error { return t.SetSpan(1, 1, 0, 0) },
+			wantErr: ErrShadowedCell,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReader(strings.NewReader(table))
+			if err != nil {
+				t.Fatalf("NewReader() = %v", err)
+			}
+			tbl, err := r.ReadAll()
+			if err != nil {
+				t.Fatalf("ReadAll() = %v", err)
+			}
+			err = tc.edit(tbl)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("edit = %v, want %v", err, tc.wantErr)
+				}
+				tc.want = table
+			} else if err != nil {
+				t.Fatalf("edit = %v", err)
+			}
+			got, err := tbl.String()
+			if err != nil {
+				t.Fatalf("String() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("String() =\n%v\nwant:\n%v\ndiff (-want +got)\n%v", got, tc.want, diff)
+			}
+		})
+	}
+}
+
+func TestTableRowSpans(t *testing.T) {
+	// A 3x2 table where the first cell spans all three rows.
+	tbl, err := NewTable(Config{Columns: []ColumnSpec{{Width: 3}, {Width: 3}}}, [][]*Cell{
+		{{Text: "A", RowSpan: 2}, {Text: "B"}},
+		{nil, {Text: "C"}},
+		{nil, {Text: "D"}},
+	})
+	if err != nil {
+		t.Fatalf("NewTable() = %v", err)
+	}
+	if err := tbl.InsertRow(1); err != nil {
+		t.Fatalf("InsertRow() = %v", err)
+	}
+	if err := tbl.DeleteRow(0); err != nil {
+		t.Fatalf("DeleteRow() = %v", err)
+	}
+	want := [][]*Cell{
+		{{Text: "A", RowSpan: 2}, {}},
+		{nil, {Text: "C"}},
+		{nil, {Text: "D"}},
+	}
+	if diff := cmp.Diff(want, tbl.Rows()); diff != "" {
+		t.Errorf("Rows() diff (-want +got)\n%v", diff)
+	}
+	if i, j, err := tbl.Owner(2, 0); err != nil || i != 0 || j != 0 {
+		t.Errorf("Owner(2, 0) = %v, %v, %v, want 0, 0, nil", i, j, err)
+	}
+}
+
+func TestNewTableErrors(t *testing.T) {
+	columns := []ColumnSpec{{Width: 3}, {Width: 3}}
+	for _, tc := range []struct {
+		name    string
+		rows    [][]*Cell
+		wantErr error
+	}{
+		{
+			name:    "missing cell",
+			rows:    [][]*Cell{{{Text: "A"}, nil}},
+			wantErr: ErrShadowedCell,
+		},
+		{
+			name:    "overlapping spans",
+			rows:    [][]*Cell{{{ColSpan: 1}, {}}},
+			wantErr: ErrOverlappingSpans,
+		},
+		{
+			name:    "span out of range",
+			rows:    [][]*Cell{{{}, {ColSpan: 1}}},
+			wantErr: ErrColumnIndexOutOfRange,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewTable(Config{Columns: columns}, tc.rows); !errors.Is(err, tc.wantErr) {
+				t.Errorf("NewTable() = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestTableSortRows(t *testing.T) {
+	tbl, err := NewTable(Config{NumHeaderRows: 1, Columns: []ColumnSpec{{Width: 3}, {Width: 3}}}, [][]*Cell{
+		{{Text: "K"}, {Text: "V"}},
+		{{Text: "3"}, {Text: "c"}},
+		{{Text: "2", RowSpan: 1}, {Text: "b"}},
+		{nil, {Text: "b2"}},
+		{{Text: "1"}, {Text: "a"}},
+	})
+	if err != nil {
+		t.Fatalf("NewTable() = %v", err)
+	}
+	keys := tbl.Rows()
+	tbl.SortRows(func(a, b int) int {
+		return strings.Compare(keys[a][0].Text, keys[b][0].Text)
+	})
+	want := [][]*Cell{
+		{{Text: "K"}, {Text: "V"}},
+		{{Text: "1"}, {Text: "a"}},
+		{{Text: "2", RowSpan: 1}, {Text: "b"}},
+		{nil, {Text: "b2"}},
+		{{Text: "3"}, {Text: "c"}},
+	}
+	if diff := cmp.Diff(want, tbl.Rows()); diff != "" {
+		t.Errorf("Rows() diff (-want +got)\n%v", diff)
+	}
+}
+
+func TestTableTranspose(t *testing.T) {
+	r, err := NewReader(strings.NewReader(`+------+-----+-----+
+| Name | Red | Sky |
++======+=====+=====+
+| Hue  | 0   | 200 |
++------+-----+-----+
+| Sat  | Both      |
++------+-----+-----+
+`))
+	if err != nil {
+		t.Fatalf("NewReader() = %v", err)
+	}
+	tbl, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() = %v", err)
+	}
+	transposed, err := tbl.Transpose(1)
+	if err != nil {
+		t.Fatalf("Transpose() = %v", err)
+	}
+	want := [][]*Cell{
+		{{Text: "Name"}, {Text: "Hue"}, {Text: "Sat"}},
+		{{Text: "Red"}, {Text: "0"}, {Text: "Both", RowSpan: 1}},
+		{{Text: "Sky"}, {Text: "200"}, nil},
+	}
+	if diff := cmp.Diff(want, transposed.Rows()); diff != "" {
+		t.Errorf("Rows() diff (-want +got)\n%v", diff)
+	}
+	transposed.FitColumns(22)
+	if diff := cmp.Diff([]ColumnSpec{{Width: 6}, {Width: 5}, {Width: 6}}, transposed.Config().Columns); diff != "" {
+		t.Errorf("Columns diff (-want +got)\n%v", diff)
+	}
+	// The row span can be written and read back.
+	text, err := transposed.String()
+	if err != nil {
+		t.Fatalf("String() = %v", err)
+	}
+	r, err = NewReader(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("NewReader() = %v", err)
+	}
+	reread, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() = %v\n%v", err, text)
+	}
+	if diff := cmp.Diff(want, reread.Rows()); diff != "" {
+		t.Errorf("reread Rows() diff (-want +got)\n%v", diff)
+	}
+	// The span can't cross the end of the header.
+	if _, err := tbl.Transpose(2); !errors.Is(err, ErrSpanBeyondHeader) {
+		t.Errorf("Transpose() = %v, want %v", err, ErrSpanBeyondHeader)
+	}
+}
@@ -0,0 +1,254 @@
+package gridtable
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WidthKind is the kind of a WidthSpec.
+type WidthKind int
+
+const (
+	// WidthAbsolute is a width in number of characters.
+	WidthAbsolute WidthKind = iota
+	// WidthPercent is a width as a percentage of the space available to the columns of the table.
+	WidthPercent
+	// WidthRemaining takes up the space left over by the other columns, shared evenly with any other WidthRemaining
+	// columns.
+	WidthRemaining
+	// WidthKeep keeps the current width of the column.
+	WidthKeep
+)
+
+// A WidthSpec describes how wide a column should be. It is parsed from one of the following forms:
+//
+//	30     30 characters
+//	25%    25% of the space available to the columns of the table
+//	*      the remaining space
+//	=      the current width of the column
+//
+// Any of these can be followed by bounds in square brackets, e.g., "*[10:40]", "25%[8:]" or "=[:30]".
+type WidthSpec struct {
+	Kind WidthKind
+	// The number of characters (WidthAbsolute) or percentage (WidthPercent).
+	Value int
+	// The minimum width of the column. 0 = no minimum.
+	Min int
+	// The maximum width of the column. 0 = no maximum.
+	Max int
+}
+
+// ParseWidthSpec parses a single WidthSpec.
+func ParseWidthSpec(s string) (WidthSpec, error) {
+	var result WidthSpec
+	spec := strings.TrimSpace(s)
+	if open := strings.IndexByte(spec, '['); open != -1 {
+		if !strings.HasSuffix(spec, "]") {
+			return WidthSpec{}, fmt.Errorf("%w: %q: missing ']'", ErrInvalidColumnSpec, s)
+		}
+		bounds := strings.Split(spec[open+1:len(spec)-1], ":")
+		if len(bounds) != 2 {
+			return WidthSpec{}, fmt.Errorf("%w: %q: bounds must be of the form [min:max]", ErrInvalidColumnSpec, s)
+		}
+		var err error
+		if result.Min, err = parseBound(bounds[0]); err != nil {
+			return WidthSpec{}, fmt.Errorf("%w: %q: %v", ErrInvalidColumnSpec, s, err)
+		}
+		if result.Max, err = parseBound(bounds[1]); err != nil {
+			return WidthSpec{}, fmt.Errorf("%w: %q: %v", ErrInvalidColumnSpec, s, err)
+		}
+		if result.Max != 0 && result.Min > result.Max {
+			return WidthSpec{}, fmt.Errorf("%w: %q: minimum is greater than maximum", ErrInvalidColumnSpec, s)
+		}
+		spec = strings.TrimSpace(spec[:open])
+	}
+	switch {
+	case spec == "*":
+		result.Kind = WidthRemaining
+	case spec == "=":
+		result.Kind = WidthKeep
+	case strings.HasSuffix(spec, "%"):
+		pct, err := strconv.Atoi(strings.TrimSuffix(spec, "%"))
+		if err != nil || pct <= 0 || pct > 100 {
+			return WidthSpec{}, fmt.Errorf("%w: %q: percentage must be between 1 and 100", ErrInvalidColumnSpec, s)
+		}
+		result.Kind = WidthPercent
+		result.Value = pct
+	default:
+		width, err := strconv.Atoi(spec)
+		if err != nil {
+			return WidthSpec{}, fmt.Errorf("%w: %q: expected a number, percentage, '*' or '='", ErrInvalidColumnSpec, s)
+		}
+		if width < minColumnWidth {
+			return WidthSpec{}, fmt.Errorf("%w: %q: width must be at least %d", ErrInvalidColumnSpec, s, minColumnWidth)
+		}
+		result.Kind = WidthAbsolute
+		result.Value = width
+	}
+	return result, nil
+}
+
+func parseBound(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	bound, err := strconv.Atoi(s)
+	if err != nil || bound < 0 {
+		return 0, fmt.Errorf("bound %q must be a non-negative integer", s)
+	}
+	return bound, nil
+}
+
+// ParseWidthSpecs parses a comma-separated list of WidthSpecs.
+func ParseWidthSpecs(s string) ([]WidthSpec, error) {
+	var result []WidthSpec
+	for _, field := range strings.Split(s, ",") {
+		spec, err := ParseWidthSpec(field)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, spec)
+	}
+	return result, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *WidthSpec) UnmarshalText(text []byte) error {
+	spec, err := ParseWidthSpec(string(text))
+	if err != nil {
+		return err
+	}
+	*s = spec
+	return nil
+}
+
+// String implements Stringer.
+func (s WidthSpec) String() string {
+	var result string
+	switch s.Kind {
+	case WidthAbsolute:
+		result = strconv.Itoa(s.Value)
+	case WidthPercent:
+		result = fmt.Sprintf("%d%%", s.Value)
+	case WidthRemaining:
+		result = "*"
+	case WidthKeep:
+		result = "="
+	}
+	if s.Min != 0 || s.Max != 0 {
+		result += "["
+		if s.Min != 0 {
+			result += strconv.Itoa(s.Min)
+		}
+		result += ":"
+		if s.Max != 0 {
+			result += strconv.Itoa(s.Max)
+		}
+		result += "]"
+	}
+	return result
+}
+
+// clamp applies the bounds of the spec to a width.
+func (s WidthSpec) clamp(width int) int {
+	if s.Min != 0 && width < s.Min {
+		width = s.Min
+	}
+	if s.Max != 0 && width > s.Max {
+		width = s.Max
+	}
+	return width
+}
+
+// ResolveWidths computes the widths of the columns of a table from specs.
+// current is the current specification of the columns (used by WidthKeep), and tableWidth is the target total width
+// of the table including separators (used by WidthPercent and WidthRemaining).
+func ResolveWidths(specs []WidthSpec, current []ColumnSpec, tableWidth int) ([]ColumnSpec, error) {
+	if len(specs) != len(current) {
+		return nil, fmt.Errorf("%w: %d widths were given for a table with %d columns", ErrInvalidColumnSpec, len(specs), len(current))
+	}
+	available := tableWidth - len(current) - 1
+	// Keep everything but the widths of the columns.
+	result := slices.Clone(current)
+	remaining := available
+	var stars []int
+	for j, spec := range specs {
+		switch spec.Kind {
+		case WidthAbsolute:
+			result[j].Width = spec.clamp(spec.Value)
+		case WidthPercent:
+			result[j].Width = spec.clamp(available * spec.Value / 100)
+		case WidthKeep:
+			result[j].Width = spec.clamp(current[j].Width)
+		case WidthRemaining:
+			stars = append(stars, j)
+			continue
+		}
+		remaining -= result[j].Width
+	}
+	// Share the remaining space between the '*' columns. If any column's share is out of bounds, fix it at the bound
+	// and share out what's left between the others.
+	for len(stars) != 0 {
+		var unbounded []int
+		spent := 0
+		for n, j := range stars {
+			share := remaining / len(stars)
+			if n < remaining%len(stars) {
+				share++
+			}
+			result[j].Width = specs[j].clamp(share)
+			if result[j].Width != share {
+				spent += result[j].Width
+				continue
+			}
+			unbounded = append(unbounded, j)
+		}
+		if len(unbounded) == len(stars) {
+			break
+		}
+		remaining -= spent
+		stars = unbounded
+	}
+	for j, col := range result {
+		if col.Width < minColumnWidth {
+			return nil, fmt.Errorf("%w: column %d resolved to width %d (minimum: %d) from %q with table width %d", ErrInvalidColumnSpec, j, col.Width, minColumnWidth, specs[j], tableWidth)
+		}
+	}
+	return result, nil
+}
+
+// FitWidths shares space characters (not counting the borders) between columns according to their contents.
+// natural[j] is the width column j would need to fit its text without wrapping, and minimum[j] the width it needs to
+// fit its longest word (both including the padding), or 0 if unknown. Each column gets at least its minimum width, and
+// then the columns share the space that's left over in proportion to how much more they need to reach their natural
+// width. If no natural widths are known, the columns share the space evenly.
+func FitWidths(space int, natural []int, minimum []int) []int {
+	result := make([]int, len(natural))
+	remaining := space
+	totalExtra := 0
+	knownNatural := false
+	for j := range result {
+		result[j] = max(minimum[j], minColumnWidth)
+		remaining -= result[j]
+		totalExtra += max(natural[j]-result[j], 0)
+		knownNatural = knownNatural || natural[j] != 0
+	}
+	if remaining <= 0 {
+		return result
+	}
+	for j := range result {
+		if !knownNatural {
+			result[j] += remaining / len(result)
+			continue
+		}
+		if totalExtra == 0 {
+			break
+		}
+		extra := max(natural[j]-result[j], 0)
+		result[j] += min(remaining*extra/totalExtra, extra)
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package gridtable
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseWidthSpecs(t *testing.T) {
+	for i, tc := range []struct {
+		str  string
+		want []WidthSpec
+	}{
+		{
+			str: "10,20,30",
+			want: []WidthSpec{
+				{Kind: WidthAbsolute, Value: 10},
+				{Kind: WidthAbsolute, Value: 20},
+				{Kind: WidthAbsolute, Value: 30},
+			},
+		},
+		{
+			str: "20%,*,=",
+			want: []WidthSpec{
+				{Kind: WidthPercent, Value: 20},
+				{Kind: WidthRemaining},
+				{Kind: WidthKeep},
+			},
+		},
+		{
+			str: "25%[8:], *[10:40], =[:30]",
+			want: []WidthSpec{
+				{Kind: WidthPercent, Value: 25, Min: 8},
+				{Kind: WidthRemaining, Min: 10, Max: 40},
+				{Kind: WidthKeep, Max: 30},
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("spec_%v", i), func(t *testing.T) {
+			got, err := ParseWidthSpecs(tc.str)
+			if err != nil {
+				t.Fatalf("ParseWidthSpecs() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParseWidthSpecs() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestParseInvalidWidthSpec(t *testing.T) {
+	for i, tc := range []string{
+		"",
+		"abc",
+		"2",
+		"0%",
+		"101%",
+		"*[10:5]",
+		"*[10]",
+		"*[10:",
+		"=[a:]",
+	} {
+		t.Run(fmt.Sprintf("spec_%v", i), func(t *testing.T) {
+			_, err := ParseWidthSpec(tc)
+			if !errors.Is(err, ErrInvalidColumnSpec) {
+				t.Errorf("ParseWidthSpec(%q) = %v, want %v", tc, err, ErrInvalidColumnSpec)
+			}
+		})
+	}
+}
+
+func TestResolveWidths(t *testing.T) {
+	for i, tc := range []struct {
+		specs      string
+		current    []int
+		tableWidth int
+		want       []int
+	}{
+		{
+			specs:      "10,20,30",
+			current:    []int{5, 5, 5},
+			tableWidth: 100,
+			want:       []int{10, 20, 30},
+		},
+		{
+			// 96 characters are available for the columns.
+			specs:      "25%,*,=",
+			current:    []int{5, 5, 5},
+			tableWidth: 100,
+			want:       []int{24, 67, 5},
+		},
+		{
+			specs:      "*,*,*",
+			current:    []int{5, 5, 5},
+			tableWidth: 24,
+			want:       []int{7, 7, 6},
+		},
+		{
+			specs:      "*[:10],*,*[30:]",
+			current:    []int{5, 5, 5},
+			tableWidth: 64,
+			want:       []int{10, 20, 30},
+		},
+		{
+			specs:      "=[10:],=[:20]",
+			current:    []int{5, 50},
+			tableWidth: 100,
+			want:       []int{10, 20},
+		},
+	} {
+		t.Run(fmt.Sprintf("spec_%v", i), func(t *testing.T) {
+			specs, err := ParseWidthSpecs(tc.specs)
+			if err != nil {
+				t.Fatalf("ParseWidthSpecs() = %v", err)
+			}
+			var current []ColumnSpec
+			for _, width := range tc.current {
+				current = append(current, ColumnSpec{Width: width})
+			}
+			got, err := ResolveWidths(specs, current, tc.tableWidth)
+			if err != nil {
+				t.Fatalf("ResolveWidths() = %v", err)
+			}
+			var gotWidths []int
+			for _, col := range got {
+				gotWidths = append(gotWidths, col.Width)
+			}
+			if diff := cmp.Diff(tc.want, gotWidths); diff != "" {
+				t.Errorf("ResolveWidths() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestResolveWidthsNoSpace(t *testing.T) {
+	specs, err := ParseWidthSpecs("50,*")
+	if err != nil {
+		t.Fatalf("ParseWidthSpecs() = %v", err)
+	}
+	_, err = ResolveWidths(specs, []ColumnSpec{{Width: 5}, {Width: 5}}, 40)
+	if !errors.Is(err, ErrInvalidColumnSpec) {
+		t.Errorf("ResolveWidths() = %v, want %v", err, ErrInvalidColumnSpec)
+	}
+}
+
+func TestParseAlignment(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want Alignment
+	}{
+		{"default", AlignDefault},
+		{"Left", AlignLeft},
+		{"r", AlignRight},
+		{" center ", AlignCenter},
+	} {
+		got, err := ParseAlignment(tc.s)
+		if err != nil {
+			t.Fatalf("ParseAlignment(%q) = %v", tc.s, err)
+		}
+		if got != tc.want {
+			t.Errorf("ParseAlignment(%q) = %v, want %v", tc.s, got, tc.want)
+		}
+	}
+	if _, err := ParseAlignment("justify"); !errors.Is(err, ErrInvalidColumnSpec) {
+		t.Errorf("ParseAlignment(\"justify\") = %v, want %v", err, ErrInvalidColumnSpec)
+	}
+}
@@ -189,14 +189,14 @@ func (w *Writer) String() (string, error) {
 	// and expand the affected rows evenly until the span is satisfied.
 	for i := range w.cells {
 		for j := range w.config.Columns {
-			if w.cells[i][j].ColSpan > 0 {
+			if span := w.cells[i][j].RowSpan; span > 0 {
 				heightToAdd := cellHeights[i][j] - rowHeights[i]
-				for _, rowHeight := range rowHeights[i+1 : i+w.cells[i][j].RowSpan+1] {
+				for _, rowHeight := range rowHeights[i+1 : i+span+1] {
 					heightToAdd -= rowHeight + 1 // we save a row from the separator here, too.
 				}
 				if heightToAdd > 0 {
-					heightToAddToEachRow := (heightToAdd + w.cells[i][j].ColSpan - 1) / w.cells[i][j].ColSpan
-					for row := i; row <= i+w.cells[i][j].ColSpan; row++ {
+					heightToAddToEachRow := (heightToAdd + span) / (span + 1)
+					for row := i; row <= i+span; row++ {
 						rowHeights[row] += heightToAddToEachRow
 					}
 				}
@@ -267,6 +267,22 @@ func (w *Writer) String() (string, error) {
 		y += rowHeights[i] + 1 // move the cursor to the y position of the next cell
 	}
 
+	// Mark the alignment of the columns on the header separator, or the top line if there is no header.
+	y = 0
+	for _, rowHeight := range rowHeights[:min(w.config.NumHeaderRows, len(rowHeights))] {
+		y += rowHeight + 1
+	}
+	x = 1
+	for _, col := range w.config.Columns {
+		if col.Align == AlignLeft || col.Align == AlignCenter {
+			array[x][y] = ':'
+		}
+		if col.Align == AlignRight || col.Align == AlignCenter {
+			array[x+col.Width-1][y] = ':'
+		}
+		x += col.Width + 1
+	}
+
 	// Draw the contents of all the (non-shadowed) cells.
 	y = 1
 	for i := range w.cells {
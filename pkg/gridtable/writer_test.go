@@ -66,6 +66,35 @@ func TestWriteBasicTable(t *testing.T) {
 +---+---+
 | C | D |
 +===+===+
+`,
+		},
+		{
+			config: Config{
+				NumHeaderRows: 1,
+				Columns: []ColumnSpec{
+					{Width: 3, Align: AlignLeft},
+					{Width: 4, Align: AlignCenter},
+				},
+			},
+			want: `+---+----+
+| A | B  |
++:==+:==:+
+| C | D  |
++---+----+
+`,
+		},
+		{
+			config: Config{
+				Columns: []ColumnSpec{
+					{Width: 3, Align: AlignRight},
+					{Width: 3},
+				},
+			},
+			want: `+--:+---+
+| A | B |
++---+---+
+| C | D |
++---+---+
 `,
 		},
 	} {
@@ -397,6 +426,45 @@ func TestWriteSmallDoubleSpanTable(t *testing.T) {
 	}
 }
 
+// Build a table with a cell that needs more lines than the rows it spans.
+func TestWriteTallRowSpan(t *testing.T) {
+	config := Config{
+		Columns: []ColumnSpec{
+			{Width: 7},
+			{Width: 3},
+		},
+	}
+	want := `+-------+---+
+| one   | B |
+| two   |   |
++ three +---+
+| four  | D |
+| five  |   |
++-------+---+
+`
+	w, err := NewWriter(config)
+	if err != nil {
+		t.Fatalf("NewWriter() = %v", err)
+	}
+	if err := w.WriteColumn(0, Cell{Text: "one two three four five", RowSpan: 1}); err != nil {
+		t.Fatalf("WriteColumn() = %v", err)
+	}
+	if err := w.WriteColumn(1, Cell{Text: "B"}); err != nil {
+		t.Fatalf("WriteColumn() = %v", err)
+	}
+	w.NextRow()
+	if err := w.WriteColumn(1, Cell{Text: "D"}); err != nil {
+		t.Fatalf("WriteColumn() = %v", err)
+	}
+	got, err := w.String()
+	if err != nil {
+		t.Fatalf("String() = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("String() =\n%v\nwant:\n%v\ndiff (-want +got)\n%v", got, want, diff)
+	}
+}
+
 func TestWriteWordWrap(t *testing.T) {
 	config := Config{
 		Columns: []ColumnSpec{
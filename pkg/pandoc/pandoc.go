@@ -0,0 +1,262 @@
+// Package pandoc implements a library for reading and writing the Pandoc JSON AST.
+//
+// Elements of the AST that pandoctor doesn't need to understand are kept as generic Elements, so that documents can be
+// round-tripped without losing anything.
+package pandoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrMalformedAST indicates that the JSON did not have the structure of a Pandoc AST.
+	ErrMalformedAST = errors.New("malformed Pandoc AST")
+)
+
+// A Document is a whole Pandoc document.
+type Document struct {
+	APIVersion []int                      `json:"pandoc-api-version"`
+	Meta       map[string]json.RawMessage `json:"meta"`
+	Blocks     []*Element                 `json:"blocks"`
+}
+
+// ReadDocument reads a Pandoc document in JSON form.
+func ReadDocument(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedAST, err)
+	}
+	if doc.Meta == nil {
+		doc.Meta = map[string]json.RawMessage{}
+	}
+	if doc.Blocks == nil {
+		doc.Blocks = []*Element{}
+	}
+	return &doc, nil
+}
+
+// Write writes the document in JSON form.
+func (d *Document) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(d)
+}
+
+// An Element is a block or inline element of the AST, e.g. {"t": "Para", "c": [...]}.
+type Element struct {
+	// The type of the element, e.g. "Para".
+	T string
+	// The contents of the element, or nil for elements without contents (e.g. "Space").
+	C json.RawMessage
+}
+
+type element struct {
+	T string          `json:"t"`
+	C json.RawMessage `json:"c,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *Element) MarshalJSON() ([]byte, error) {
+	return marshal(element{T: e.T, C: e.C})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Element) UnmarshalJSON(data []byte) error {
+	var elem element
+	if err := json.Unmarshal(data, &elem); err != nil {
+		return err
+	}
+	if elem.T == "" {
+		return fmt.Errorf("%w: element has no type", ErrMalformedAST)
+	}
+	e.T = elem.T
+	e.C = elem.C
+	return nil
+}
+
+// NewElement creates an element with the given type and contents. A nil c creates an element without contents.
+func NewElement(t string, c any) *Element {
+	if c == nil {
+		return &Element{T: t}
+	}
+	data, err := marshal(c)
+	if err != nil {
+		// All of the contents we construct are plain data.
+		panic(fmt.Sprintf("unexpected error marshaling %v contents: %v", t, err))
+	}
+	return &Element{T: t, C: data}
+}
+
+// Str creates a Str inline.
+func Str(text string) *Element {
+	return NewElement("Str", text)
+}
+
+// Space creates a Space inline.
+func Space() *Element {
+	return NewElement("Space", nil)
+}
+
+// Text creates a sequence of Str and Space inlines from plain text.
+func Text(text string) []*Element {
+	var result []*Element
+	for i, word := range strings.Fields(text) {
+		if i != 0 {
+			result = append(result, Space())
+		}
+		result = append(result, Str(word))
+	}
+	return result
+}
+
+// Plain creates a Plain block.
+func Plain(inlines []*Element) *Element {
+	return NewElement("Plain", nonNil(inlines))
+}
+
+// Para creates a Para block.
+func Para(inlines []*Element) *Element {
+	return NewElement("Para", nonNil(inlines))
+}
+
+// RawBlock creates a RawBlock.
+func RawBlock(format string, text string) *Element {
+	return NewElement("RawBlock", []string{format, text})
+}
+
+// RawBlockText returns the format and text of a RawBlock, or ok=false if e is not a RawBlock.
+func (e *Element) RawBlockText() (format string, text string, ok bool) {
+	if e.T != "RawBlock" {
+		return "", "", false
+	}
+	return e.rawText()
+}
+
+// RawText returns the format and text of a RawInline, or ok=false if e is not a RawInline.
+func (e *Element) RawText() (format string, text string, ok bool) {
+	if e.T != "RawInline" {
+		return "", "", false
+	}
+	return e.rawText()
+}
+
+// CodeBlockText returns the attributes and text of a CodeBlock, or ok=false if e is not a CodeBlock.
+func (e *Element) CodeBlockText() (attr Attr, text string, ok bool) {
+	if e.T != "CodeBlock" || decodeFields(e.C, "CodeBlock", &attr, &text) != nil {
+		return Attr{}, "", false
+	}
+	return attr, text, true
+}
+
+// Children returns the contents of an element whose contents are just a list of elements, e.g. a Para or an Emph.
+// It returns nil for other elements.
+func (e *Element) Children() []*Element {
+	var children []*Element
+	if err := json.Unmarshal(e.C, &children); err != nil {
+		return nil
+	}
+	return children
+}
+
+func (e *Element) rawText() (format string, text string, ok bool) {
+	var c []string
+	if err := json.Unmarshal(e.C, &c); err != nil || len(c) != 2 {
+		return "", "", false
+	}
+	return c[0], c[1], true
+}
+
+// marshal is like json.Marshal, but doesn't escape HTML characters. Documents often contain raw HTML, and this keeps
+// it readable.
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// nonNil returns an empty slice instead of nil, so that it is marshaled as [] instead of null.
+func nonNil[T any](ts []T) []T {
+	if ts == nil {
+		return []T{}
+	}
+	return ts
+}
+
+// decodeArray decodes a JSON array of exactly n elements.
+func decodeArray(data []byte, n int, what string) ([]json.RawMessage, error) {
+	var result []json.RawMessage
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v: %v", ErrMalformedAST, what, err)
+	}
+	if len(result) != n {
+		return nil, fmt.Errorf("%w: %v should have %d fields, not %d", ErrMalformedAST, what, n, len(result))
+	}
+	return result, nil
+}
+
+// decodeFields decodes each element of a JSON array into the given pointers.
+func decodeFields(data []byte, what string, fields ...any) error {
+	raw, err := decodeArray(data, len(fields), what)
+	if err != nil {
+		return err
+	}
+	for i, field := range fields {
+		if err := json.Unmarshal(raw[i], field); err != nil {
+			return fmt.Errorf("%w: %v field %d: %v", ErrMalformedAST, what, i, err)
+		}
+	}
+	return nil
+}
+
+// Stringify returns the plain text of a list of inlines or blocks, without any formatting.
+func Stringify(elems []*Element) string {
+	var sb strings.Builder
+	stringify(&sb, elems)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func stringify(sb *strings.Builder, elems []*Element) {
+	for _, e := range elems {
+		switch e.T {
+		case "Str":
+			var text string
+			json.Unmarshal(e.C, &text)
+			sb.WriteString(text)
+		case "Space", "SoftBreak", "LineBreak":
+			sb.WriteString(" ")
+		case "Code", "Math", "RawInline":
+			// [x, text]: the text is always last.
+			var c []json.RawMessage
+			json.Unmarshal(e.C, &c)
+			if len(c) != 0 {
+				var text string
+				json.Unmarshal(c[len(c)-1], &text)
+				sb.WriteString(text)
+			}
+		case "Emph", "Underline", "Strong", "Strikeout", "Superscript", "Subscript", "SmallCaps", "Plain", "Para",
+			"BlockQuote":
+			stringify(sb, e.Children())
+			if e.T == "Para" || e.T == "Plain" || e.T == "BlockQuote" {
+				sb.WriteString(" ")
+			}
+		case "Quoted", "Cite", "Span", "Link", "Image":
+			// [x, [Inline], ...]: the inlines are always second.
+			var c []json.RawMessage
+			json.Unmarshal(e.C, &c)
+			if len(c) >= 2 {
+				var children []*Element
+				json.Unmarshal(c[1], &children)
+				stringify(sb, children)
+			}
+		}
+	}
+}
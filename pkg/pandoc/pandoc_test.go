@@ -0,0 +1,92 @@
+package pandoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testDocument = `{"pandoc-api-version":[1,23,1],"meta":{"title":{"t":"MetaInlines","c":[{"t":"Str","c":"Doc"}]}},"blocks":[` +
+	`{"t":"Para","c":[{"t":"Str","c":"Some"},{"t":"Space"},{"t":"Emph","c":[{"t":"Str","c":"text"}]},{"t":"Space"},{"t":"Code","c":[["",[],[]],"x<y"]}]},` +
+	`{"t":"RawBlock","c":["html","<table>"]},` +
+	`{"t":"BlockQuote","c":[{"t":"Para","c":[{"t":"Link","c":[["",[],[]],[{"t":"Str","c":"a"},{"t":"SoftBreak"},{"t":"Str","c":"link"}],["https://example.com",""]]}]}]}` +
+	`]}`
+
+func TestRoundTrip(t *testing.T) {
+	doc, err := ReadDocument(strings.NewReader(testDocument))
+	if err != nil {
+		t.Fatalf("ReadDocument() = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	if diff := cmp.Diff(testDocument+"\n", buf.String()); diff != "" {
+		t.Errorf("Write() diff (-want +got)\n%v", diff)
+	}
+}
+
+func TestReadMalformedDocument(t *testing.T) {
+	for i, tc := range []string{
+		`not json`,
+		`{"blocks":[{"c":"no type"}]}`,
+		`{"blocks":{}}`,
+	} {
+		t.Run(fmt.Sprintf("doc_%v", i), func(t *testing.T) {
+			if _, err := ReadDocument(strings.NewReader(tc)); err == nil {
+				t.Errorf("ReadDocument() = nil, want error")
+			}
+		})
+	}
+}
+
+func TestStringify(t *testing.T) {
+	doc, err := ReadDocument(strings.NewReader(testDocument))
+	if err != nil {
+		t.Fatalf("ReadDocument() = %v", err)
+	}
+	want := "Some text x<y a link"
+	if got := Stringify(doc.Blocks); got != want {
+		t.Errorf("Stringify() = %q, want %q", got, want)
+	}
+}
+
+func TestRawBlockText(t *testing.T) {
+	format, text, ok := RawBlock("html", "<br>").RawBlockText()
+	if !ok || format != "html" || text != "<br>" {
+		t.Errorf("RawBlockText() = %q, %q, %v, want \"html\", \"<br>\", true", format, text, ok)
+	}
+	if _, _, ok := Para(Text("hi")).RawBlockText(); ok {
+		t.Errorf("RawBlockText() of Para = ok, want !ok")
+	}
+}
+
+func TestCodeBlockText(t *testing.T) {
+	var elem Element
+	if err := json.Unmarshal([]byte(`{"t":"CodeBlock","c":[["",["table"],[["source","a.csv"]]],"x"]}`), &elem); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	attr, text, ok := elem.CodeBlockText()
+	want := Attr{Classes: []string{"table"}, KeyVals: [][2]string{{"source", "a.csv"}}}
+	if !ok || !cmp.Equal(attr, want) || text != "x" {
+		t.Errorf("CodeBlockText() = %+v, %q, %v, want %+v, \"x\", true", attr, text, ok, want)
+	}
+	if _, _, ok := Para(Text("hi")).CodeBlockText(); ok {
+		t.Errorf("CodeBlockText() of Para = ok, want !ok")
+	}
+}
+
+func TestText(t *testing.T) {
+	got, err := json.Marshal(Plain(Text("  two\nwords ")))
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+	want := `{"t":"Plain","c":[{"t":"Str","c":"two"},{"t":"Space"},{"t":"Str","c":"words"}]}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Text() diff (-want +got)\n%v", diff)
+	}
+}
@@ -0,0 +1,214 @@
+package pandoc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Attr is the attributes of an element: ["id", ["class", ...], [["key", "val"], ...]].
+type Attr struct {
+	ID      string
+	Classes []string
+	KeyVals [][2]string
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a Attr) MarshalJSON() ([]byte, error) {
+	return marshal([]any{a.ID, nonNil(a.Classes), nonNil(a.KeyVals)})
+}
+
+// IsEmpty returns whether there are no attributes.
+func (a *Attr) IsEmpty() bool {
+	return a.ID == "" && len(a.Classes) == 0 && len(a.KeyVals) == 0
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Attr) UnmarshalJSON(data []byte) error {
+	return decodeFields(data, "Attr", &a.ID, &a.Classes, &a.KeyVals)
+}
+
+// Alignment is the alignment of a table column or cell, e.g. "AlignDefault" or "AlignLeft".
+type Alignment string
+
+// The alignments of table columns and cells.
+const (
+	AlignDefault Alignment = "AlignDefault"
+	AlignLeft    Alignment = "AlignLeft"
+	AlignRight   Alignment = "AlignRight"
+	AlignCenter  Alignment = "AlignCenter"
+)
+
+// MarshalJSON implements json.Marshaler.
+func (a Alignment) MarshalJSON() ([]byte, error) {
+	if a == "" {
+		a = AlignDefault
+	}
+	return marshal(element{T: string(a)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Alignment) UnmarshalJSON(data []byte) error {
+	var elem element
+	if err := json.Unmarshal(data, &elem); err != nil {
+		return err
+	}
+	*a = Alignment(elem.T)
+	return nil
+}
+
+// A ColSpec is the specification of a column: [Alignment, ColWidth].
+type ColSpec struct {
+	Alignment Alignment
+	// The width of the column as a fraction of the text width. 0 = ColWidthDefault.
+	Width float64
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c ColSpec) MarshalJSON() ([]byte, error) {
+	width := NewElement("ColWidthDefault", nil)
+	if c.Width != 0 {
+		width = NewElement("ColWidth", c.Width)
+	}
+	return marshal([]any{c.Alignment, width})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *ColSpec) UnmarshalJSON(data []byte) error {
+	var width Element
+	if err := decodeFields(data, "ColSpec", &c.Alignment, &width); err != nil {
+		return err
+	}
+	c.Width = 0
+	if width.T == "ColWidth" {
+		if err := json.Unmarshal(width.C, &c.Width); err != nil {
+			return fmt.Errorf("%w: ColWidth: %v", ErrMalformedAST, err)
+		}
+	}
+	return nil
+}
+
+// A Cell is a table cell: [Attr, Alignment, RowSpan, ColSpan, [Block]].
+// Note that unlike gridtable.Cell, the spans count the cell itself, so a cell that doesn't span has spans of 1.
+type Cell struct {
+	Attr      Attr
+	Alignment Alignment
+	RowSpan   int
+	ColSpan   int
+	Blocks    []*Element
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Cell) MarshalJSON() ([]byte, error) {
+	return marshal([]any{c.Attr, c.Alignment, c.RowSpan, c.ColSpan, nonNil(c.Blocks)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Cell) UnmarshalJSON(data []byte) error {
+	return decodeFields(data, "Cell", &c.Attr, &c.Alignment, &c.RowSpan, &c.ColSpan, &c.Blocks)
+}
+
+// A Row is a table row: [Attr, [Cell]].
+type Row struct {
+	Attr  Attr
+	Cells []Cell
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r Row) MarshalJSON() ([]byte, error) {
+	return marshal([]any{r.Attr, nonNil(r.Cells)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Row) UnmarshalJSON(data []byte) error {
+	return decodeFields(data, "Row", &r.Attr, &r.Cells)
+}
+
+// A TableHead is the head of a table: [Attr, [Row]]. TableFoot has the same structure.
+type TableHead struct {
+	Attr Attr
+	Rows []Row
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h TableHead) MarshalJSON() ([]byte, error) {
+	return marshal([]any{h.Attr, nonNil(h.Rows)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *TableHead) UnmarshalJSON(data []byte) error {
+	return decodeFields(data, "TableHead", &h.Attr, &h.Rows)
+}
+
+// A TableBody is a body of a table: [Attr, RowHeadColumns, [Row], [Row]].
+type TableBody struct {
+	Attr Attr
+	// The number of columns that are row headers.
+	RowHeadColumns int
+	// The intermediate head of the body.
+	Head []Row
+	Rows []Row
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b TableBody) MarshalJSON() ([]byte, error) {
+	return marshal([]any{b.Attr, b.RowHeadColumns, nonNil(b.Head), nonNil(b.Rows)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *TableBody) UnmarshalJSON(data []byte) error {
+	return decodeFields(data, "TableBody", &b.Attr, &b.RowHeadColumns, &b.Head, &b.Rows)
+}
+
+// A Caption is the caption of a table: [ShortCaption, [Block]].
+type Caption struct {
+	// The short caption (a list of inlines), or nil if there is none.
+	Short []*Element
+	Long  []*Element
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Caption) MarshalJSON() ([]byte, error) {
+	return marshal([]any{c.Short, nonNil(c.Long)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Caption) UnmarshalJSON(data []byte) error {
+	return decodeFields(data, "Caption", &c.Short, &c.Long)
+}
+
+// A Table is a table: [Attr, Caption, [ColSpec], TableHead, [TableBody], TableFoot].
+type Table struct {
+	Attr     Attr
+	Caption  Caption
+	ColSpecs []ColSpec
+	Head     TableHead
+	Bodies   []TableBody
+	Foot     TableHead
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Table) MarshalJSON() ([]byte, error) {
+	return marshal([]any{t.Attr, t.Caption, nonNil(t.ColSpecs), t.Head, nonNil(t.Bodies), t.Foot})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Table) UnmarshalJSON(data []byte) error {
+	return decodeFields(data, "Table", &t.Attr, &t.Caption, &t.ColSpecs, &t.Head, &t.Bodies, &t.Foot)
+}
+
+// Table decodes a Table element. isTable is false if e is not a Table element.
+func (e *Element) Table() (table *Table, isTable bool, err error) {
+	if e.T != "Table" {
+		return nil, false, nil
+	}
+	var t Table
+	if err := json.Unmarshal(e.C, &t); err != nil {
+		return nil, true, err
+	}
+	return &t, true, nil
+}
+
+// Element returns the table as a Table element.
+func (t *Table) Element() *Element {
+	return NewElement("Table", t)
+}
@@ -0,0 +1,57 @@
+package pandoc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testTable = `{"t":"Table","c":[` +
+	`["tbl-regs",["wide"],[]],` +
+	`[null,[{"t":"Plain","c":[{"t":"Str","c":"Registers"}]}]],` +
+	`[[{"t":"AlignLeft"},{"t":"ColWidth","c":0.25}],[{"t":"AlignDefault"},{"t":"ColWidthDefault"}]],` +
+	`[["",[],[]],[[["",[],[]],[[["",[],[]],{"t":"AlignDefault"},1,2,[{"t":"Plain","c":[{"t":"Str","c":"Name"}]}]]]]]],` +
+	`[[["",[],[]],0,[],[[["",[],[]],[[["",[],[]],{"t":"AlignDefault"},1,1,[]],[["",[],[]],{"t":"AlignDefault"},1,1,[{"t":"Para","c":[{"t":"Str","c":"x"}]}]]]]]]],` +
+	`[["",[],[]],[]]]}`
+
+func TestTable(t *testing.T) {
+	var elem Element
+	if err := json.Unmarshal([]byte(testTable), &elem); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	table, isTable, err := elem.Table()
+	if err != nil || !isTable {
+		t.Fatalf("Table() = %v, %v, %v", table, isTable, err)
+	}
+	if diff := cmp.Diff(Attr{ID: "tbl-regs", Classes: []string{"wide"}, KeyVals: [][2]string{}}, table.Attr); diff != "" {
+		t.Errorf("Attr diff (-want +got)\n%v", diff)
+	}
+	if got := Stringify(table.Caption.Long); got != "Registers" {
+		t.Errorf("caption = %q, want \"Registers\"", got)
+	}
+	if diff := cmp.Diff([]ColSpec{{"AlignLeft", 0.25}, {AlignDefault, 0}}, table.ColSpecs); diff != "" {
+		t.Errorf("ColSpecs diff (-want +got)\n%v", diff)
+	}
+	if got := table.Head.Rows[0].Cells[0].ColSpan; got != 2 {
+		t.Errorf("ColSpan = %v, want 2", got)
+	}
+	if got := len(table.Bodies[0].Rows[0].Cells); got != 2 {
+		t.Errorf("body row has %v cells, want 2", got)
+	}
+
+	// Round-trip the table.
+	got, err := json.Marshal(table.Element())
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+	if diff := cmp.Diff(testTable, string(got)); diff != "" {
+		t.Errorf("Marshal() diff (-want +got)\n%v", diff)
+	}
+}
+
+func TestNotTable(t *testing.T) {
+	if table, isTable, err := Para(Text("hi")).Table(); table != nil || isTable || err != nil {
+		t.Errorf("Table() = %v, %v, %v, want nil, false, nil", table, isTable, err)
+	}
+}
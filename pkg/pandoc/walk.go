@@ -0,0 +1,139 @@
+package pandoc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// A BlockListFunc is called on each list of blocks in a document. It returns the new list of blocks.
+type BlockListFunc func(blocks []*Element) ([]*Element, error)
+
+// WalkBlockLists calls f on the document's list of blocks and on every list of blocks nested inside them (in block
+// quotes, divs, lists, figures and table cells), innermost first.
+// Lists of blocks are visited (rather than individual blocks) so that f can replace runs of several blocks at once.
+func (d *Document) WalkBlockLists(f BlockListFunc) error {
+	blocks, err := walkBlockList(d.Blocks, f)
+	if err != nil {
+		return err
+	}
+	d.Blocks = blocks
+	return nil
+}
+
+func walkBlockList(blocks []*Element, f BlockListFunc) ([]*Element, error) {
+	for _, block := range blocks {
+		if err := walkBlock(block, f); err != nil {
+			return nil, err
+		}
+	}
+	return f(blocks)
+}
+
+// walkBlock calls walkBlockList on the lists of blocks nested inside the given block.
+func walkBlock(block *Element, f BlockListFunc) error {
+	var err error
+	switch block.T {
+	case "BlockQuote":
+		// [Block]
+		var blocks []*Element
+		if err := json.Unmarshal(block.C, &blocks); err != nil {
+			return fmt.Errorf("%w: %v: %v", ErrMalformedAST, block.T, err)
+		}
+		if blocks, err = walkBlockList(blocks, f); err != nil {
+			return err
+		}
+		*block = *NewElement(block.T, blocks)
+	case "Div", "Figure":
+		// [Attr, [Block]] or [Attr, Caption, [Block]]: the blocks are always last.
+		var c []json.RawMessage
+		if err := json.Unmarshal(block.C, &c); err != nil || len(c) < 2 {
+			return fmt.Errorf("%w: %v", ErrMalformedAST, block.T)
+		}
+		var blocks []*Element
+		if err := json.Unmarshal(c[len(c)-1], &blocks); err != nil {
+			return fmt.Errorf("%w: %v: %v", ErrMalformedAST, block.T, err)
+		}
+		if blocks, err = walkBlockList(blocks, f); err != nil {
+			return err
+		}
+		if c[len(c)-1], err = marshal(blocks); err != nil {
+			return err
+		}
+		*block = *NewElement(block.T, c)
+	case "BulletList":
+		// [[Block]]
+		var items [][]*Element
+		if err := json.Unmarshal(block.C, &items); err != nil {
+			return fmt.Errorf("%w: %v: %v", ErrMalformedAST, block.T, err)
+		}
+		for i := range items {
+			if items[i], err = walkBlockList(items[i], f); err != nil {
+				return err
+			}
+		}
+		*block = *NewElement(block.T, items)
+	case "OrderedList":
+		// [ListAttributes, [[Block]]]
+		var attrs json.RawMessage
+		var items [][]*Element
+		if err := decodeFields(block.C, block.T, &attrs, &items); err != nil {
+			return err
+		}
+		for i := range items {
+			if items[i], err = walkBlockList(items[i], f); err != nil {
+				return err
+			}
+		}
+		*block = *NewElement(block.T, []any{attrs, items})
+	case "DefinitionList":
+		// [([Inline], [[Block]])]
+		var items [][2]json.RawMessage
+		if err := json.Unmarshal(block.C, &items); err != nil {
+			return fmt.Errorf("%w: %v: %v", ErrMalformedAST, block.T, err)
+		}
+		for i := range items {
+			var definitions [][]*Element
+			if err := json.Unmarshal(items[i][1], &definitions); err != nil {
+				return fmt.Errorf("%w: %v: %v", ErrMalformedAST, block.T, err)
+			}
+			for j := range definitions {
+				if definitions[j], err = walkBlockList(definitions[j], f); err != nil {
+					return err
+				}
+			}
+			if items[i][1], err = marshal(definitions); err != nil {
+				return err
+			}
+		}
+		*block = *NewElement(block.T, items)
+	case "Table":
+		table, _, err := block.Table()
+		if err != nil {
+			return err
+		}
+		var rows []*Row
+		for i := range table.Head.Rows {
+			rows = append(rows, &table.Head.Rows[i])
+		}
+		for i := range table.Bodies {
+			for j := range table.Bodies[i].Head {
+				rows = append(rows, &table.Bodies[i].Head[j])
+			}
+			for j := range table.Bodies[i].Rows {
+				rows = append(rows, &table.Bodies[i].Rows[j])
+			}
+		}
+		for i := range table.Foot.Rows {
+			rows = append(rows, &table.Foot.Rows[i])
+		}
+		for _, row := range rows {
+			for i := range row.Cells {
+				if row.Cells[i].Blocks, err = walkBlockList(row.Cells[i].Blocks, f); err != nil {
+					return err
+				}
+			}
+		}
+		*block = *table.Element()
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package pandoc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWalkBlockLists(t *testing.T) {
+	input := `{"pandoc-api-version":[1,23,1],"meta":{},"blocks":[` +
+		`{"t":"RawBlock","c":["html","<hr>"]},` +
+		`{"t":"BlockQuote","c":[{"t":"RawBlock","c":["html","<hr>"]}]},` +
+		`{"t":"Div","c":[["",[],[]],[{"t":"RawBlock","c":["html","<hr>"]}]]},` +
+		`{"t":"BulletList","c":[[{"t":"RawBlock","c":["html","<hr>"]}]]},` +
+		`{"t":"OrderedList","c":[[1,{"t":"Decimal"},{"t":"Period"}],[[{"t":"RawBlock","c":["html","<hr>"]}]]]},` +
+		`{"t":"DefinitionList","c":[[[{"t":"Str","c":"term"}],[[{"t":"RawBlock","c":["html","<hr>"]}]]]]}` +
+		`]}`
+	doc, err := ReadDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadDocument() = %v", err)
+	}
+	lists := 0
+	err = doc.WalkBlockLists(func(blocks []*Element) ([]*Element, error) {
+		lists++
+		for i, block := range blocks {
+			if _, text, ok := block.RawBlockText(); ok && text == "<hr>" {
+				blocks[i] = Para(Text("rule"))
+			}
+		}
+		return blocks, nil
+	})
+	if err != nil {
+		t.Fatalf("WalkBlockLists() = %v", err)
+	}
+	if lists != 6 {
+		t.Errorf("WalkBlockLists() visited %v lists, want 6", lists)
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	want := strings.ReplaceAll(input, `{"t":"RawBlock","c":["html","<hr>"]}`, `{"t":"Para","c":[{"t":"Str","c":"rule"}]}`) + "\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("WalkBlockLists() diff (-want +got)\n%v", diff)
+	}
+}
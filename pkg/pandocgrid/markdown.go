@@ -0,0 +1,238 @@
+package pandocgrid
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/pandoc"
+)
+
+// Markdown renders the contents of a table cell as Markdown, with paragraphs separated by blank lines.
+// Inline formatting is kept; other blocks (e.g., lists) are reduced to their plain text.
+func Markdown(blocks []*pandoc.Element) string {
+	var paragraphs []string
+	for _, block := range blocks {
+		var text string
+		switch block.T {
+		case "Plain", "Para":
+			var sb strings.Builder
+			writeInlines(&sb, block.Children())
+			text = sb.String()
+		default:
+			text = pandoc.Stringify([]*pandoc.Element{block})
+		}
+		if text = strings.Join(strings.Fields(text), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// inlineDelimiters are the Markdown delimiters around inlines whose contents are just a list of inlines.
+// Inlines that aren't listed here are replaced by their contents.
+var inlineDelimiters = map[string]string{
+	"Emph":        "*",
+	"Strong":      "**",
+	"Strikeout":   "~~",
+	"Superscript": "^",
+	"Subscript":   "~",
+}
+
+func writeInlines(sb *strings.Builder, inlines []*pandoc.Element) {
+	for _, inline := range inlines {
+		switch inline.T {
+		case "Str":
+			var text string
+			json.Unmarshal(inline.C, &text)
+			sb.WriteString(escape(text))
+		case "Space", "SoftBreak", "LineBreak":
+			// Line breaks can't survive the text being re-wrapped inside the cell.
+			sb.WriteString(" ")
+		case "Code":
+			code := pandoc.Stringify([]*pandoc.Element{inline})
+			fence := "`"
+			for strings.Contains(code, fence) {
+				fence += "`"
+			}
+			if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+				code = " " + code + " "
+			}
+			sb.WriteString(fence + code + fence)
+		case "Math":
+			// [MathType, text]
+			var c []json.RawMessage
+			var mathType pandoc.Element
+			var text string
+			if json.Unmarshal(inline.C, &c) == nil && len(c) == 2 && json.Unmarshal(c[0], &mathType) == nil && json.Unmarshal(c[1], &text) == nil {
+				delim := "$"
+				if mathType.T == "DisplayMath" {
+					delim = "$$"
+				}
+				sb.WriteString(delim + text + delim)
+			}
+		case "RawInline":
+			if format, text, ok := inline.RawText(); ok && (format == "html" || strings.HasPrefix(format, "markdown")) {
+				sb.WriteString(text)
+			}
+		case "Link", "Image":
+			// [Attr, [Inline], [url, title]]
+			var c []json.RawMessage
+			var children []*pandoc.Element
+			var target []string
+			if json.Unmarshal(inline.C, &c) != nil || len(c) != 3 || json.Unmarshal(c[1], &children) != nil || json.Unmarshal(c[2], &target) != nil || len(target) != 2 {
+				continue
+			}
+			if inline.T == "Image" {
+				sb.WriteString("!")
+			}
+			sb.WriteString("[")
+			writeInlines(sb, children)
+			sb.WriteString("](" + target[0] + ")")
+		case "Note":
+			// Notes can't be represented inside a cell.
+		default:
+			if delim, ok := inlineDelimiters[inline.T]; ok {
+				sb.WriteString(delim)
+				writeInlines(sb, inline.Children())
+				sb.WriteString(delim)
+			} else {
+				sb.WriteString(escape(pandoc.Stringify([]*pandoc.Element{inline})))
+			}
+		}
+	}
+}
+
+// markdownEscaper escapes the characters that would otherwise be read as Markdown formatting.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "~", `\~`, "^", `\^`, "$", `\$`)
+
+func escape(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// Blocks parses the text of a grid table cell into Pandoc blocks. Like Pandoc, a cell with a single paragraph becomes
+// a Plain, and a cell with several paragraphs becomes a Para for each paragraph.
+func Blocks(text string) []*pandoc.Element {
+	var paragraphs []string
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if strings.TrimSpace(paragraph) != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	var result []*pandoc.Element
+	for _, paragraph := range paragraphs {
+		if len(paragraphs) == 1 {
+			result = append(result, pandoc.Plain(Inlines(paragraph)))
+		} else {
+			result = append(result, pandoc.Para(Inlines(paragraph)))
+		}
+	}
+	return result
+}
+
+// Inlines parses a paragraph of Markdown into Pandoc inlines. Only the formatting that Markdown produces is understood:
+// emphasis, strong emphasis, strikeout, code spans and backslash escapes.
+func Inlines(text string) []*pandoc.Element {
+	var result []*pandoc.Element
+	var word strings.Builder
+	flush := func() {
+		if word.Len() != 0 {
+			result = append(result, pandoc.Str(word.String()))
+			word.Reset()
+		}
+	}
+	space := func() {
+		flush()
+		if len(result) != 0 && result[len(result)-1].T != "Space" {
+			result = append(result, pandoc.Space())
+		}
+	}
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\*_`[]~^$", text[i+1]) != -1:
+			word.WriteByte(text[i+1])
+			i += 2
+			continue
+		case c == ' ' || c == '\t' || c == '\n':
+			space()
+			i++
+			continue
+		case c == '`':
+			fence := text[i : i+len(text[i:])-len(strings.TrimLeft(text[i:], "`"))]
+			if end := strings.Index(text[i+len(fence):], fence); end != -1 {
+				flush()
+				code := text[i+len(fence) : i+len(fence)+end]
+				if strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ") && strings.TrimSpace(code) != "" {
+					code = code[1 : len(code)-1]
+				}
+				result = append(result, pandoc.NewElement("Code", []any{pandoc.Attr{}, code}))
+				i += 2*len(fence) + end
+				continue
+			}
+		default:
+			if t, delim, ok := openingDelimiter(text[i:], word.Len() == 0); ok {
+				if end := closingDelimiter(text[i+len(delim):], delim); end != -1 {
+					flush()
+					children := Inlines(text[i+len(delim) : i+len(delim)+end])
+					result = append(result, pandoc.NewElement(t, children))
+					i += 2*len(delim) + end
+					continue
+				}
+			}
+		}
+		word.WriteByte(c)
+		i++
+	}
+	flush()
+	if len(result) != 0 && result[len(result)-1].T == "Space" {
+		result = result[:len(result)-1]
+	}
+	if result == nil {
+		return []*pandoc.Element{}
+	}
+	return result
+}
+
+// openingDelimiter returns the type of inline and the delimiter if text begins with the delimiter of an inline.
+// Underscores are only delimiters at the start of a word.
+func openingDelimiter(text string, startOfWord bool) (string, string, bool) {
+	for _, d := range []struct{ t, delim string }{
+		{"Strong", "**"},
+		{"Strong", "__"},
+		{"Strikeout", "~~"},
+		{"Emph", "*"},
+		{"Emph", "_"},
+		{"Subscript", "~"},
+		{"Superscript", "^"},
+	} {
+		if !strings.HasPrefix(text, d.delim) || (d.delim[0] == '_' && !startOfWord) {
+			continue
+		}
+		// The delimiter must be followed by something other than whitespace.
+		if len(text) == len(d.delim) || strings.IndexByte(" \t\n", text[len(d.delim)]) != -1 {
+			continue
+		}
+		return d.t, d.delim, true
+	}
+	return "", "", false
+}
+
+// closingDelimiter returns the offset of the delimiter that closes an inline in text, or -1 if there isn't one.
+func closingDelimiter(text string, delim string) int {
+	for i := 1; i+len(delim) <= len(text); i++ {
+		if text[i-1] == '\\' {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(text[i:], delim) || strings.IndexByte(" \t\n", text[i-1]) != -1 {
+			continue
+		}
+		// Don't close "*" with the first half of a "**".
+		if len(delim) == 1 && strings.HasPrefix(text[i+1:], delim) {
+			i++
+			continue
+		}
+		return i
+	}
+	return -1
+}
@@ -0,0 +1,70 @@
+package pandocgrid
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/chrisfenner/pandoctor/pkg/pandoc"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	for i, tc := range []struct {
+		blocks []*pandoc.Element
+		want   string
+	}{
+		{
+			blocks: []*pandoc.Element{pandoc.Plain(pandoc.Text("plain text"))},
+			want:   "plain text",
+		},
+		{
+			blocks: []*pandoc.Element{pandoc.Plain([]*pandoc.Element{
+				pandoc.NewElement("Emph", pandoc.Text("some emphasis")),
+				pandoc.Space(),
+				pandoc.Str("and"),
+				pandoc.Space(),
+				pandoc.NewElement("Strong", []*pandoc.Element{pandoc.Str("strong")}),
+				pandoc.Space(),
+				pandoc.NewElement("Code", []any{pandoc.Attr{}, "x*y"}),
+			})},
+			want: "*some emphasis* and **strong** `x*y`",
+		},
+		{
+			blocks: []*pandoc.Element{pandoc.Plain(pandoc.Text("snake_case and 2*3"))},
+			want:   `snake\_case and 2\*3`,
+		},
+		{
+			blocks: []*pandoc.Element{
+				pandoc.Para(pandoc.Text("First paragraph.")),
+				pandoc.Para([]*pandoc.Element{pandoc.NewElement("Strikeout", pandoc.Text("Second"))}),
+			},
+			want: "First paragraph.\n\n~~Second~~",
+		},
+	} {
+		t.Run(fmt.Sprintf("markdown_%v", i), func(t *testing.T) {
+			got := Markdown(tc.blocks)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("Markdown() diff (-want +got)\n%v", diff)
+			}
+			if diff := cmp.Diff(tc.blocks, Blocks(got)); diff != "" {
+				t.Errorf("Blocks() diff (-want +got)\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestInlinesUnclosedDelimiters(t *testing.T) {
+	for i, tc := range []string{
+		"2 * 3 * 4",
+		"an *unclosed emphasis",
+		"a `dangling backtick",
+		"snake_case_name",
+	} {
+		t.Run(fmt.Sprintf("inlines_%v", i), func(t *testing.T) {
+			got := pandoc.Stringify(Inlines(tc))
+			if got != tc {
+				t.Errorf("Stringify(Inlines(%q)) = %q", tc, got)
+			}
+		})
+	}
+}
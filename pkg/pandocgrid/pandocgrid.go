@@ -0,0 +1,226 @@
+// Package pandocgrid converts between Pandoc AST tables and grid tables.
+//
+// The conversion keeps the structure of the table (header rows, spans, caption and attributes) and the relative widths
+// of the columns. Features that grid tables can't represent are flattened: intermediate heads and table feet become
+// ordinary body rows, and cell alignments and attributes are dropped. The contents of the cells are converted to and
+// from a simple subset of Markdown (see Markdown and Blocks).
+package pandocgrid
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/chrisfenner/pandoctor/pkg/pandoc"
+)
+
+var (
+	// ErrUnsupportedTable indicates that the Pandoc table can't be represented as a grid table.
+	ErrUnsupportedTable = errors.New("unsupported table")
+)
+
+const (
+	// The narrowest column that can be written (see gridtable.NewWriter).
+	minColumnWidth = 3
+)
+
+// ColumnSpecs converts Pandoc column specs into grid table columns for a table tableWidth characters wide.
+// Columns with the default width share the space that's left over evenly.
+func ColumnSpecs(specs []pandoc.ColSpec, tableWidth int) []gridtable.ColumnSpec {
+	return columnSpecs(specs, tableWidth, make([]int, len(specs)), make([]int, len(specs)))
+}
+
+// columnSpecs converts Pandoc column specs into grid table columns for a table tableWidth characters wide.
+// Columns with the default width share the space that's left over according to their natural and minimum widths
+// (see gridtable.FitWidths).
+func columnSpecs(specs []pandoc.ColSpec, tableWidth int, natural []int, minimum []int) []gridtable.ColumnSpec {
+	available := tableWidth - len(specs) - 1
+	result := make([]gridtable.ColumnSpec, len(specs))
+	remaining := available
+	var defaults, defaultNatural, defaultMinimum []int
+	for j, spec := range specs {
+		result[j].Align = alignments[spec.Alignment]
+		if spec.Width == 0 {
+			defaults = append(defaults, j)
+			defaultNatural = append(defaultNatural, natural[j])
+			defaultMinimum = append(defaultMinimum, minimum[j])
+			continue
+		}
+		result[j].Width = max(int(math.Round(spec.Width*float64(available))), minColumnWidth)
+		remaining -= result[j].Width
+	}
+	for n, width := range gridtable.FitWidths(remaining, defaultNatural, defaultMinimum) {
+		result[defaults[n]].Width = width
+	}
+	return result
+}
+
+// ColSpecs converts grid table columns into Pandoc column specs, relative to a table tableWidth characters wide.
+func ColSpecs(columns []gridtable.ColumnSpec, tableWidth int) []pandoc.ColSpec {
+	available := float64(tableWidth - len(columns) - 1)
+	result := make([]pandoc.ColSpec, len(columns))
+	for j, col := range columns {
+		result[j].Width = float64(col.Width) / available
+		for alignment, align := range alignments {
+			if align == col.Align && align != gridtable.AlignDefault {
+				result[j].Alignment = alignment
+			}
+		}
+	}
+	return result
+}
+
+// alignments maps Pandoc alignments to grid table alignments.
+var alignments = map[pandoc.Alignment]gridtable.Alignment{
+	pandoc.AlignDefault: gridtable.AlignDefault,
+	pandoc.AlignLeft:    gridtable.AlignLeft,
+	pandoc.AlignRight:   gridtable.AlignRight,
+	pandoc.AlignCenter:  gridtable.AlignCenter,
+}
+
+// FromTable converts a Pandoc table into a grid table block for a table tableWidth characters wide.
+// The caption (if any) comes before the table.
+func FromTable(table *pandoc.Table, tableWidth int) (*gridtable.Block, error) {
+	numColumns := len(table.ColSpecs)
+	if numColumns == 0 {
+		return nil, fmt.Errorf("%w: table has no columns", ErrUnsupportedTable)
+	}
+	rows := append([]pandoc.Row{}, table.Head.Rows...)
+	for _, body := range table.Bodies {
+		rows = append(rows, body.Head...)
+		rows = append(rows, body.Rows...)
+	}
+	rows = append(rows, table.Foot.Rows...)
+
+	result := gridtable.Block{
+		Config: gridtable.Config{
+			NumHeaderRows: len(table.Head.Rows),
+		},
+	}
+	// Pandoc rows only contain the cells that start in that row, so keep track of which columns of the following rows
+	// are covered by row spans.
+	var covered [][]bool
+	natural := make([]int, numColumns)
+	minimum := make([]int, numColumns)
+	for i, row := range rows {
+		for len(covered) <= i {
+			covered = append(covered, make([]bool, numColumns))
+		}
+		gridRow := make([]*gridtable.Cell, numColumns)
+		j := 0
+		for _, cell := range row.Cells {
+			for j < numColumns && covered[i][j] {
+				j++
+			}
+			rowSpan, colSpan := max(cell.RowSpan, 1), max(cell.ColSpan, 1)
+			if j+colSpan > numColumns {
+				return nil, fmt.Errorf("%w: row %d has more than %d columns", ErrUnsupportedTable, i+1, numColumns)
+			}
+			if i < result.Config.NumHeaderRows && i+rowSpan > result.Config.NumHeaderRows {
+				return nil, fmt.Errorf("%w: cell in row %d spans beyond the header", ErrUnsupportedTable, i+1)
+			}
+			// Pandoc truncates row spans at the end of the table.
+			rowSpan = min(rowSpan, len(rows)-i)
+			text := Markdown(cell.Blocks)
+			gridRow[j] = &gridtable.Cell{
+				Text:    text,
+				RowSpan: rowSpan - 1,
+				ColSpan: colSpan - 1,
+			}
+			if colSpan == 1 {
+				natural[j] = max(natural[j], naturalWidth(text))
+				minimum[j] = max(minimum[j], minimumWidth(text))
+			}
+			for di := range rowSpan {
+				for len(covered) <= i+di {
+					covered = append(covered, make([]bool, numColumns))
+				}
+				for dj := range colSpan {
+					covered[i+di][j+dj] = true
+				}
+			}
+			j += colSpan
+		}
+		// Grid tables can't have missing cells, so fill in any gaps with empty ones.
+		for j := range numColumns {
+			if !covered[i][j] {
+				gridRow[j] = &gridtable.Cell{}
+				covered[i][j] = true
+			}
+		}
+		result.Rows = append(result.Rows, gridRow)
+	}
+	result.Config.Columns = columnSpecs(table.ColSpecs, tableWidth, natural, minimum)
+
+	if len(table.Caption.Long) != 0 || !table.Attr.IsEmpty() {
+		result.Caption = &gridtable.Caption{
+			Prefix: "Table:",
+			Text:   strings.Join(strings.Fields(Markdown(table.Caption.Long)), " "),
+			Attributes: gridtable.Attributes{
+				ID:      table.Attr.ID,
+				Classes: table.Attr.Classes,
+				KeyVals: table.Attr.KeyVals,
+			},
+		}
+		result.CaptionPosition = gridtable.CaptionBefore
+	}
+	return &result, nil
+}
+
+// naturalWidth returns the width a column would need to fit the text without wrapping, including the padding.
+func naturalWidth(text string) int {
+	result := 0
+	for _, line := range strings.Split(text, "\n") {
+		result = max(result, len([]rune(line))+2)
+	}
+	return result
+}
+
+// minimumWidth returns the width a column needs to fit the longest word of the text, including the padding.
+func minimumWidth(text string) int {
+	result := 0
+	for _, word := range strings.Fields(text) {
+		result = max(result, len([]rune(word))+2)
+	}
+	return result
+}
+
+// ToTable converts a grid table block into a Pandoc table, with column widths relative to a table tableWidth
+// characters wide.
+func ToTable(block *gridtable.Block, tableWidth int) *pandoc.Table {
+	result := pandoc.Table{
+		ColSpecs: ColSpecs(block.Config.Columns, tableWidth),
+		Bodies:   []pandoc.TableBody{{}},
+	}
+	if block.Caption != nil {
+		result.Attr = pandoc.Attr{
+			ID:      block.Caption.Attributes.ID,
+			Classes: block.Caption.Attributes.Classes,
+			KeyVals: block.Caption.Attributes.KeyVals,
+		}
+		if block.Caption.Text != "" {
+			result.Caption.Long = []*pandoc.Element{pandoc.Plain(Inlines(block.Caption.Text))}
+		}
+	}
+	for i, gridRow := range block.Rows {
+		var row pandoc.Row
+		for _, cell := range gridRow {
+			if cell == nil {
+				continue
+			}
+			row.Cells = append(row.Cells, pandoc.Cell{
+				RowSpan: cell.RowSpan + 1,
+				ColSpan: cell.ColSpan + 1,
+				Blocks:  Blocks(cell.Text),
+			})
+		}
+		if i < block.Config.NumHeaderRows {
+			result.Head.Rows = append(result.Head.Rows, row)
+		} else {
+			result.Bodies[0].Rows = append(result.Bodies[0].Rows, row)
+		}
+	}
+	return &result
+}
@@ -0,0 +1,124 @@
+package pandocgrid
+
+import (
+	"testing"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/chrisfenner/pandoctor/pkg/pandoc"
+	"github.com/google/go-cmp/cmp"
+)
+
+func cell(text string, rowSpan int, colSpan int) pandoc.Cell {
+	return pandoc.Cell{
+		RowSpan: rowSpan,
+		ColSpan: colSpan,
+		Blocks:  []*pandoc.Element{pandoc.Plain(pandoc.Text(text))},
+	}
+}
+
+func TestFromTable(t *testing.T) {
+	table := pandoc.Table{
+		Attr: pandoc.Attr{ID: "tbl-regs"},
+		Caption: pandoc.Caption{
+			Long: []*pandoc.Element{pandoc.Plain(pandoc.Text("Registers"))},
+		},
+		ColSpecs: []pandoc.ColSpec{{Alignment: pandoc.AlignLeft, Width: 0.25}, {Width: 0.25}, {Alignment: pandoc.AlignRight, Width: 0.5}},
+		Head: pandoc.TableHead{
+			Rows: []pandoc.Row{
+				{Cells: []pandoc.Cell{cell("Name", 1, 2), cell("Description", 1, 1)}},
+			},
+		},
+		Bodies: []pandoc.TableBody{{
+			Rows: []pandoc.Row{
+				{Cells: []pandoc.Cell{cell("A", 2, 1), cell("a1", 1, 1), cell("first", 1, 1)}},
+				{Cells: []pandoc.Cell{cell("a2", 1, 1), cell("second", 1, 1)}},
+			},
+		}},
+		Foot: pandoc.TableHead{
+			Rows: []pandoc.Row{
+				{Cells: []pandoc.Cell{cell("Total", 1, 3)}},
+			},
+		},
+	}
+	block, err := FromTable(&table, 44)
+	if err != nil {
+		t.Fatalf("FromTable() = %v", err)
+	}
+	got, err := block.String()
+	if err != nil {
+		t.Fatalf("String() = %v", err)
+	}
+	want := `Table: Registers {#tbl-regs}
+
++----------+----------+--------------------+
+| Name                | Description        |
++:=========+==========+===================:+
+| A        | a1       | first              |
++          +----------+--------------------+
+|          | a2       | second             |
++----------+----------+--------------------+
+| Total                                    |
++----------+----------+--------------------+
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FromTable() diff (-want +got)\n%v", diff)
+	}
+
+	// Convert it back. The foot has become part of the body.
+	back := ToTable(block, 44)
+	table.Bodies[0].Rows = append(table.Bodies[0].Rows, table.Foot.Rows...)
+	table.Foot.Rows = nil
+	if diff := cmp.Diff(&table, back); diff != "" {
+		t.Errorf("ToTable() diff (-want +got)\n%v", diff)
+	}
+}
+
+func TestFromTableDefaultWidths(t *testing.T) {
+	table := pandoc.Table{
+		ColSpecs: []pandoc.ColSpec{{}, {}},
+		Bodies: []pandoc.TableBody{{
+			Rows: []pandoc.Row{
+				{Cells: []pandoc.Cell{cell("short", 1, 1), cell("a somewhat longer cell that needs to be wrapped", 1, 1)}},
+				// A missing cell is filled in.
+				{Cells: []pandoc.Cell{cell("x", 1, 1)}},
+			},
+		}},
+	}
+	block, err := FromTable(&table, 40)
+	if err != nil {
+		t.Fatalf("FromTable() = %v", err)
+	}
+	if diff := cmp.Diff([]gridtable.ColumnSpec{{Width: 7}, {Width: 30}}, block.Config.Columns); diff != "" {
+		t.Errorf("FromTable() columns diff (-want +got)\n%v", diff)
+	}
+	if block.Rows[1][1] == nil || block.Rows[1][1].Text != "" {
+		t.Errorf("FromTable() missing cell = %v, want empty cell", block.Rows[1][1])
+	}
+}
+
+func TestFromTableErrors(t *testing.T) {
+	for name, table := range map[string]pandoc.Table{
+		"no columns": {},
+		"too many cells": {
+			ColSpecs: []pandoc.ColSpec{{}},
+			Bodies: []pandoc.TableBody{{
+				Rows: []pandoc.Row{{Cells: []pandoc.Cell{cell("a", 1, 1), cell("b", 1, 1)}}},
+			}},
+		},
+		"span beyond header": {
+			ColSpecs: []pandoc.ColSpec{{}},
+			Head: pandoc.TableHead{
+				Rows: []pandoc.Row{{Cells: []pandoc.Cell{cell("a", 2, 1)}}},
+			},
+			Bodies: []pandoc.TableBody{{
+				Rows: []pandoc.Row{{Cells: []pandoc.Cell{}}},
+			}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := FromTable(&table, 40); err == nil {
+				t.Errorf("FromTable() = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package tablediff
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+var (
+	// ErrIncompatible indicates that the versions of a table have different numbers of columns.
+	ErrIncompatible = errors.New("the versions of the table have different numbers of columns")
+)
+
+// The conflict markers used by Merge, in the style of git.
+const (
+	OursMarker   = "<<<<<<< ours"
+	Separator    = "======="
+	TheirsMarker = ">>>>>>> theirs"
+)
+
+// A Chunk is a section of a three-way diff: either stable (the same in all three versions) or not.
+type Chunk struct {
+	Stable bool
+	// The chunk is base[Base[0]:Base[1]], ours[Ours[0]:Ours[1]] and theirs[Theirs[0]:Theirs[1]].
+	Base, Ours, Theirs [2]int
+}
+
+// Diff3 splits three versions of a list of lines (or anything else identified by a string) into alternating stable
+// and unstable chunks, like diff3: the stable chunks are the lines of base that are in both ours and theirs.
+func Diff3(base, ours, theirs []string) []Chunk {
+	matchOurs, matchTheirs := matches(base, ours), matches(base, theirs)
+	var result []Chunk
+	o, a, b := 0, 0, 0
+	for {
+		n := 0
+		for o+n < len(base) && matchOurs[o+n] == a+n && matchTheirs[o+n] == b+n {
+			n++
+		}
+		if n != 0 {
+			result = append(result, Chunk{Stable: true, Base: [2]int{o, o + n}, Ours: [2]int{a, a + n}, Theirs: [2]int{b, b + n}})
+			o, a, b = o+n, a+n, b+n
+			continue
+		}
+		// Find the next line of base that is in both of the other versions.
+		next := o
+		for next < len(base) && (matchOurs[next] == -1 || matchTheirs[next] == -1) {
+			next++
+		}
+		if next == len(base) {
+			if o < len(base) || a < len(ours) || b < len(theirs) {
+				result = append(result, Chunk{Base: [2]int{o, len(base)}, Ours: [2]int{a, len(ours)}, Theirs: [2]int{b, len(theirs)}})
+			}
+			return result
+		}
+		result = append(result, Chunk{Base: [2]int{o, next}, Ours: [2]int{a, matchOurs[next]}, Theirs: [2]int{b, matchTheirs[next]}})
+		o, a, b = next, matchOurs[next], matchTheirs[next]
+	}
+}
+
+// matches returns the index of each element of base in other, according to their longest common subsequence, or -1
+// if it isn't in other.
+func matches(base, other []string) []int {
+	result := make([]int, len(base))
+	for i := range result {
+		result[i] = -1
+	}
+	for _, match := range commonSubsequence(base, other) {
+		result[match[0]] = match[1]
+	}
+	return result
+}
+
+// Merge merges the changes made to base in ours and in theirs, row by row and then cell by cell, ignoring whitespace.
+// Rows that were changed in both versions are paired by their first cells and merged cell by cell. The result has the
+// column widths and header of ours. A cell changed differently in ours and theirs contains both versions between
+// conflict markers, and rows added or removed differently are kept from both versions between rows of conflict
+// markers. Merge also returns the number of conflicts.
+func Merge(base, ours, theirs *gridtable.Table) (*gridtable.Table, int, error) {
+	if base.NumColumns() != ours.NumColumns() || base.NumColumns() != theirs.NumColumns() {
+		return nil, 0, ErrIncompatible
+	}
+	m := merger{
+		numColumns: ours.NumColumns(),
+		rows:       [3][][]*gridtable.Cell{base.Rows(), ours.Rows(), theirs.Rows()},
+		text:       [3][][]string{Text(base), Text(ours), Text(theirs)},
+	}
+	for v := range m.keys {
+		m.keys[v] = rowKeys(m.text[v])
+	}
+	numHeaderRows := 0
+	oursHeaderRows := ours.Config().NumHeaderRows
+	for _, c := range Diff3(m.keys[0], m.keys[1], m.keys[2]) {
+		start := len(m.result)
+		m.merge(c, false)
+		// The header ends where the header of ours ends.
+		if c.Ours[1] <= oursHeaderRows {
+			numHeaderRows = len(m.result)
+		} else if c.Ours[0] < oursHeaderRows && len(m.result)-start == c.Ours[1]-c.Ours[0] {
+			numHeaderRows = start + oursHeaderRows - c.Ours[0]
+		}
+	}
+	config := ours.Config()
+	config.NumHeaderRows = numHeaderRows
+	result, err := gridtable.NewTable(config, m.result)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, m.conflicts, nil
+}
+
+// A merger holds the state of Merge. Its arrays hold the base, ours and theirs versions of the table, in that order.
+type merger struct {
+	numColumns int
+	rows       [3][][]*gridtable.Cell
+	text       [3][][]string
+	keys       [3][]string
+	result     [][]*gridtable.Cell
+	conflicts  int
+}
+
+// merge merges a chunk of the rows of the table. If the chunk is unstable and the rows weren't already paired by their
+// first cells, they are.
+func (m *merger) merge(c Chunk, paired bool) {
+	b, o, t := m.keys[0][c.Base[0]:c.Base[1]], m.keys[1][c.Ours[0]:c.Ours[1]], m.keys[2][c.Theirs[0]:c.Theirs[1]]
+	switch {
+	case c.Stable || slices.Equal(t, b) || slices.Equal(o, t):
+		m.result = append(m.result, m.rows[1][c.Ours[0]:c.Ours[1]]...)
+	case slices.Equal(o, b):
+		m.result = append(m.result, m.rows[2][c.Theirs[0]:c.Theirs[1]]...)
+	case len(b) == len(o) && len(o) == len(t):
+		// The same rows were changed in both versions: merge them cell by cell.
+		for k := range len(b) {
+			m.mergeRow(c.Base[0]+k, c.Ours[0]+k, c.Theirs[0]+k)
+		}
+	case !paired:
+		firstCells := func(v int, r [2]int) []string {
+			var result []string
+			for _, row := range m.text[v][r[0]:r[1]] {
+				result = append(result, row[0])
+			}
+			return result
+		}
+		for _, sub := range Diff3(firstCells(0, c.Base), firstCells(1, c.Ours), firstCells(2, c.Theirs)) {
+			sub.Base = [2]int{c.Base[0] + sub.Base[0], c.Base[0] + sub.Base[1]}
+			sub.Ours = [2]int{c.Ours[0] + sub.Ours[0], c.Ours[0] + sub.Ours[1]}
+			sub.Theirs = [2]int{c.Theirs[0] + sub.Theirs[0], c.Theirs[0] + sub.Theirs[1]}
+			// Stable rows have the same first cells, but may differ in the others.
+			sub.Stable = false
+			m.merge(sub, true)
+		}
+	default:
+		m.conflict(m.rows[1][c.Ours[0]:c.Ours[1]], m.rows[2][c.Theirs[0]:c.Theirs[1]])
+	}
+}
+
+// mergeRow merges the changes made to a row in ours and in theirs, cell by cell. If the cells of the row span
+// different columns in the three versions, the row conflicts as a whole.
+func (m *merger) mergeRow(bi, oi, ti int) {
+	base, ours, theirs := m.rows[0][bi], m.rows[1][oi], m.rows[2][ti]
+	baseText, oursText, theirsText := m.text[0][bi], m.text[1][oi], m.text[2][ti]
+	row := make([]*gridtable.Cell, len(ours))
+	conflicts := 0
+	for j := range ours {
+		if !sameShape(base[j], ours[j]) || !sameShape(base[j], theirs[j]) {
+			m.conflict(m.rows[1][oi:oi+1], m.rows[2][ti:ti+1])
+			return
+		}
+		switch {
+		case ours[j] == nil:
+		case oursText[j] == theirsText[j] || theirsText[j] == baseText[j]:
+			row[j] = ours[j]
+		case oursText[j] == baseText[j]:
+			row[j] = theirs[j]
+		default:
+			cell := *ours[j]
+			cell.Text = strings.Join([]string{OursMarker, ours[j].Text, Separator, theirs[j].Text, TheirsMarker}, "\n")
+			row[j] = &cell
+			conflicts++
+		}
+	}
+	m.result = append(m.result, row)
+	m.conflicts += conflicts
+}
+
+// conflict adds the rows of ours and theirs between rows of conflict markers that span the whole table.
+func (m *merger) conflict(ours, theirs [][]*gridtable.Cell) {
+	marker := func(text string) []*gridtable.Cell {
+		row := make([]*gridtable.Cell, m.numColumns)
+		row[0] = &gridtable.Cell{Text: text, ColSpan: m.numColumns - 1}
+		return row
+	}
+	m.result = append(m.result, marker(OursMarker))
+	m.result = append(m.result, ours...)
+	m.result = append(m.result, marker(Separator))
+	m.result = append(m.result, theirs...)
+	m.result = append(m.result, marker(TheirsMarker))
+	m.conflicts++
+}
+
+// rowKeys identifies each row of a table by its text.
+func rowKeys(text [][]string) []string {
+	result := make([]string, len(text))
+	for i, row := range text {
+		result[i] = strings.Join(row, "\x00")
+	}
+	return result
+}
+
+// sameShape returns whether two versions of a position in a row are both shadowed, or both cells with the same spans.
+func sameShape(a, b *gridtable.Cell) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.RowSpan == b.RowSpan && a.ColSpan == b.ColSpan
+}
@@ -0,0 +1,142 @@
+package tablediff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff3(t *testing.T) {
+	base := strings.Split("a b c d e", " ")
+	ours := strings.Split("a B c d e f", " ")
+	theirs := strings.Split("a b c D e", " ")
+	want := []Chunk{
+		{Stable: true, Base: [2]int{0, 1}, Ours: [2]int{0, 1}, Theirs: [2]int{0, 1}},
+		{Base: [2]int{1, 2}, Ours: [2]int{1, 2}, Theirs: [2]int{1, 2}},
+		{Stable: true, Base: [2]int{2, 3}, Ours: [2]int{2, 3}, Theirs: [2]int{2, 3}},
+		{Base: [2]int{3, 4}, Ours: [2]int{3, 4}, Theirs: [2]int{3, 4}},
+		{Stable: true, Base: [2]int{4, 5}, Ours: [2]int{4, 5}, Theirs: [2]int{4, 5}},
+		{Base: [2]int{5, 5}, Ours: [2]int{5, 6}, Theirs: [2]int{5, 5}},
+	}
+	if diff := cmp.Diff(want, Diff3(base, ours, theirs)); diff != "" {
+		t.Errorf("Diff3() (-want +got):\n%v", diff)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := newTable(t,
+		[]string{"Name", "Offset", "Description"},
+		[]string{"CTRL", "0", "Control."},
+		[]string{"STATUS", "4", "Status."})
+	for _, tc := range []struct {
+		name          string
+		ours          *gridtable.Table
+		theirs        *gridtable.Table
+		want          [][]string
+		wantConflicts int
+	}{
+		{
+			name: "different cells",
+			ours: newTable(t,
+				[]string{"Name", "Offset", "Description"},
+				[]string{"CTRL", "0", "Controls\nthe widget."},
+				[]string{"STATUS", "4", "Status."}),
+			theirs: newTable(t,
+				[]string{"Name", "Offset", "Description"},
+				[]string{"CTRL", "0x00", "Control."},
+				[]string{"STATUS", "4", "Status."},
+				[]string{"ID", "8", "Device ID."}),
+			want: [][]string{
+				{"Name", "Offset", "Description"},
+				{"CTRL", "0x00", "Controls the widget."},
+				{"STATUS", "4", "Status."},
+				{"ID", "8", "Device ID."},
+			},
+		},
+		{
+			name: "different rows changed and added",
+			ours: newTable(t,
+				[]string{"Name", "Offset", "Description"},
+				[]string{"CTRL", "0", "Controls the widget."},
+				[]string{"STATUS", "4", "Status."},
+				[]string{"ID", "8", "Device ID."}),
+			theirs: newTable(t,
+				[]string{"Name", "Offset", "Description"},
+				[]string{"CTRL", "0", "Control."},
+				[]string{"STATUS", "4", "Current status."}),
+			want: [][]string{
+				{"Name", "Offset", "Description"},
+				{"CTRL", "0", "Controls the widget."},
+				{"STATUS", "4", "Current status."},
+				{"ID", "8", "Device ID."},
+			},
+		},
+		{
+			name: "same cell",
+			ours: newTable(t,
+				[]string{"Name", "Offset", "Description"},
+				[]string{"CTRL", "0", "Control."},
+				[]string{"STATUS", "4", "Our status."}),
+			theirs: newTable(t,
+				[]string{"Name", "Offset", "Description"},
+				[]string{"CTRL", "0", "Control."},
+				[]string{"STATUS", "4", "Their status."}),
+			want: [][]string{
+				{"Name", "Offset", "Description"},
+				{"CTRL", "0", "Control."},
+				{"STATUS", "4", "<<<<<<< ours Our status. ======= Their status. >>>>>>> theirs"},
+			},
+			wantConflicts: 1,
+		},
+		{
+			name: "rows added in the same place",
+			ours: newTable(t,
+				[]string{"Name", "Offset", "Description"},
+				[]string{"CTRL", "0", "Control."},
+				[]string{"STATUS", "4", "Status."},
+				[]string{"ID", "8", "Device ID."}),
+			theirs: newTable(t,
+				[]string{"Name", "Offset", "Description"},
+				[]string{"CTRL", "0", "Control."},
+				[]string{"STATUS", "4", "Status."},
+				[]string{"DATA", "8", "Data."}),
+			want: [][]string{
+				{"Name", "Offset", "Description"},
+				{"CTRL", "0", "Control."},
+				{"STATUS", "4", "Status."},
+				{"<<<<<<< ours", "", ""},
+				{"ID", "8", "Device ID."},
+				{"=======", "", ""},
+				{"DATA", "8", "Data."},
+				{">>>>>>> theirs", "", ""},
+			},
+			wantConflicts: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, conflicts, err := Merge(base, tc.ours, tc.theirs)
+			if err != nil {
+				t.Fatalf("Merge() = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, Text(got)); diff != "" {
+				t.Errorf("Merge() (-want +got):\n%v", diff)
+			}
+			if conflicts != tc.wantConflicts {
+				t.Errorf("Merge() conflicts = %v, want %v", conflicts, tc.wantConflicts)
+			}
+			if got := got.Config().NumHeaderRows; got != 1 {
+				t.Errorf("NumHeaderRows = %v, want 1", got)
+			}
+		})
+	}
+}
+
+func TestMergeIncompatible(t *testing.T) {
+	base := newTable(t, []string{"a", "b"})
+	theirs := newTable(t, []string{"a", "b", "c"})
+	if _, _, err := Merge(base, base, theirs); err != ErrIncompatible {
+		t.Errorf("Merge() = %v, want %v", err, ErrIncompatible)
+	}
+}
@@ -0,0 +1,205 @@
+// Package tablediff compares and merges versions of a table cell by cell, so that reflowing the text of a cell doesn't count
+// as a change.
+package tablediff
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+)
+
+// Kind is the kind of a Change.
+type Kind string
+
+const (
+	ColumnAdded   Kind = "column added"
+	ColumnRemoved Kind = "column removed"
+	RowAdded      Kind = "row added"
+	RowRemoved    Kind = "row removed"
+	CellChanged   Kind = "cell changed"
+)
+
+// A Change is a difference between two versions of a table.
+type Change struct {
+	Kind Kind
+	// The row of the change in the old and new tables (counting from 0, including the header rows), or -1 if it isn't
+	// in that table. Column changes have no rows.
+	OldRow, NewRow int
+	// The column of the change in the old and new tables (counting from 0), or -1 if it isn't in that table. Row
+	// changes have no columns.
+	OldColumn, NewColumn int
+	// The text of the cells of the removed or added row or column, or the old and new text of the changed cell.
+	Old, New []string
+}
+
+// Text returns the text of each position in the table, with whitespace normalized. Positions covered by a spanning
+// cell are blank.
+func Text(table *gridtable.Table) [][]string {
+	result := make([][]string, table.NumRows())
+	for i := range result {
+		result[i] = make([]string, table.NumColumns())
+		for j := range result[i] {
+			if cell := table.Cell(i, j); cell != nil {
+				result[i][j] = strings.Join(strings.Fields(cell.Text), " ")
+			}
+		}
+	}
+	return result
+}
+
+// Diff compares the old and new versions of a table. Columns are paired by their headings (the text of their first row), and rows
+// by their contents, so that columns and rows can be added, removed or moved. Rows that are neither added nor removed
+// are compared cell by cell.
+//
+// The column changes come first, then the row and cell changes in the order of the rows.
+func Diff(before, after *gridtable.Table) []Change {
+	oldText, newText := Text(before), Text(after)
+	var result []Change
+	columns := pairColumns(before, after, oldText, newText)
+	paired := make(map[int]bool)
+	for _, pair := range columns {
+		paired[pair[0]] = true
+	}
+	for j := range before.NumColumns() {
+		if !paired[j] {
+			result = append(result, Change{Kind: ColumnRemoved, OldRow: -1, NewRow: -1, OldColumn: j, NewColumn: -1, Old: column(oldText, j)})
+		}
+	}
+	clear(paired)
+	for _, pair := range columns {
+		paired[pair[1]] = true
+	}
+	for j := range after.NumColumns() {
+		if !paired[j] {
+			result = append(result, Change{Kind: ColumnAdded, OldRow: -1, NewRow: -1, OldColumn: -1, NewColumn: j, New: column(newText, j)})
+		}
+	}
+
+	// Rows are compared on the columns that are in both tables.
+	key := func(text [][]string, i, side int) string {
+		var cells []string
+		for _, pair := range columns {
+			cells = append(cells, text[i][pair[side]])
+		}
+		return strings.Join(cells, "\x00")
+	}
+	oldKeys := make([]string, len(oldText))
+	for i := range oldText {
+		oldKeys[i] = key(oldText, i, 0)
+	}
+	newKeys := make([]string, len(newText))
+	for i := range newText {
+		newKeys[i] = key(newText, i, 1)
+	}
+	oi, ni := 0, 0
+	for _, match := range append(commonSubsequence(oldKeys, newKeys), [2]int{len(oldKeys), len(newKeys)}) {
+		// Between the matching rows, the rows that were removed and added in the same place are treated as changed.
+		for ; oi < match[0] && ni < match[1]; oi, ni = oi+1, ni+1 {
+			for _, pair := range columns {
+				if oldText[oi][pair[0]] != newText[ni][pair[1]] {
+					result = append(result, Change{
+						Kind:      CellChanged,
+						OldRow:    oi,
+						NewRow:    ni,
+						OldColumn: pair[0],
+						NewColumn: pair[1],
+						Old:       []string{oldText[oi][pair[0]]},
+						New:       []string{newText[ni][pair[1]]},
+					})
+				}
+			}
+		}
+		for ; oi < match[0]; oi++ {
+			result = append(result, Change{Kind: RowRemoved, OldRow: oi, NewRow: -1, OldColumn: -1, NewColumn: -1, Old: oldText[oi]})
+		}
+		for ; ni < match[1]; ni++ {
+			result = append(result, Change{Kind: RowAdded, OldRow: -1, NewRow: ni, OldColumn: -1, NewColumn: -1, New: newText[ni]})
+		}
+		oi, ni = match[0]+1, match[1]+1
+	}
+	return result
+}
+
+// pairColumns pairs the columns of the old and new versions of a table with the same heading, in order of the new table. If the
+// same number of columns are left over on each side, they are paired in order (so that renaming a column changes its
+// heading rather than replacing it). Tables without a header are paired by position.
+func pairColumns(before, after *gridtable.Table, oldText, newText [][]string) [][2]int {
+	var result [][2]int
+	if before.Config().NumHeaderRows == 0 || after.Config().NumHeaderRows == 0 {
+		for j := range min(before.NumColumns(), after.NumColumns()) {
+			result = append(result, [2]int{j, j})
+		}
+		return result
+	}
+	used := make(map[int]bool)
+	var unpaired []int
+	for j := range after.NumColumns() {
+		found := false
+		for oj := range before.NumColumns() {
+			if !used[oj] && newText[0][j] != "" && oldText[0][oj] == newText[0][j] {
+				used[oj] = true
+				result = append(result, [2]int{oj, j})
+				found = true
+				break
+			}
+		}
+		if !found {
+			unpaired = append(unpaired, j)
+		}
+	}
+	if len(unpaired) == before.NumColumns()-len(used) {
+		oj := 0
+		for _, j := range unpaired {
+			for used[oj] {
+				oj++
+			}
+			used[oj] = true
+			result = append(result, [2]int{oj, j})
+		}
+	}
+	slices.SortFunc(result, func(a, b [2]int) int {
+		return a[1] - b[1]
+	})
+	return result
+}
+
+// column returns the text of column j.
+func column(text [][]string, j int) []string {
+	var result []string
+	for _, row := range text {
+		result = append(result, row[j])
+	}
+	return result
+}
+
+// commonSubsequence returns the indexes of the elements of a longest common subsequence of a and b, in order.
+func commonSubsequence(a, b []string) [][2]int {
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	var result [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, [2]int{i, j})
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return result
+}
@@ -0,0 +1,125 @@
+package tablediff
+
+import (
+	"testing"
+
+	"github.com/chrisfenner/pandoctor/pkg/gridtable"
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTable returns a table with one header row and the given text.
+func newTable(t *testing.T, text ...[]string) *gridtable.Table {
+	t.Helper()
+	config := gridtable.Config{NumHeaderRows: 1, Columns: make([]gridtable.ColumnSpec, len(text[0]))}
+	for j := range config.Columns {
+		config.Columns[j].Width = 20
+	}
+	var rows [][]*gridtable.Cell
+	for _, row := range text {
+		var cells []*gridtable.Cell
+		for _, cellText := range row {
+			cells = append(cells, &gridtable.Cell{Text: cellText})
+		}
+		rows = append(rows, cells)
+	}
+	table, err := gridtable.NewTable(config, rows)
+	if err != nil {
+		t.Fatalf("NewTable() = %v", err)
+	}
+	return table
+}
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		before     *gridtable.Table
+		after      *gridtable.Table
+		wantChange []Change
+	}{
+		{
+			name: "reflowed",
+			before: newTable(t,
+				[]string{"Name", "Description"},
+				[]string{"CTRL", "Controls\nthe widget."}),
+			after: newTable(t,
+				[]string{"Name", "Description"},
+				[]string{"CTRL", "Controls the\nwidget."}),
+		},
+		{
+			name: "cell changed",
+			before: newTable(t,
+				[]string{"Name", "Offset"},
+				[]string{"CTRL", "0"},
+				[]string{"STATUS", "4"}),
+			after: newTable(t,
+				[]string{"Name", "Offset"},
+				[]string{"CTRL", "0"},
+				[]string{"STATUS", "8"}),
+			wantChange: []Change{
+				{Kind: CellChanged, OldRow: 2, NewRow: 2, OldColumn: 1, NewColumn: 1, Old: []string{"4"}, New: []string{"8"}},
+			},
+		},
+		{
+			name: "rows added and removed",
+			before: newTable(t,
+				[]string{"Name", "Offset"},
+				[]string{"CTRL", "0"},
+				[]string{"STATUS", "4"}),
+			after: newTable(t,
+				[]string{"Name", "Offset"},
+				[]string{"ID", "8"},
+				[]string{"CTRL", "0"}),
+			wantChange: []Change{
+				{Kind: RowAdded, OldRow: -1, NewRow: 1, OldColumn: -1, NewColumn: -1, New: []string{"ID", "8"}},
+				{Kind: RowRemoved, OldRow: 2, NewRow: -1, OldColumn: -1, NewColumn: -1, Old: []string{"STATUS", "4"}},
+			},
+		},
+		{
+			name: "columns moved, added and removed",
+			before: newTable(t,
+				[]string{"Name", "Offset", "Reset"},
+				[]string{"CTRL", "0", "1"}),
+			after: newTable(t,
+				[]string{"Offset", "Name", "Access", "Notes"},
+				[]string{"0", "CTRL", "RW", ""}),
+			wantChange: []Change{
+				{Kind: ColumnRemoved, OldRow: -1, NewRow: -1, OldColumn: 2, NewColumn: -1, Old: []string{"Reset", "1"}},
+				{Kind: ColumnAdded, OldRow: -1, NewRow: -1, OldColumn: -1, NewColumn: 2, New: []string{"Access", "RW"}},
+				{Kind: ColumnAdded, OldRow: -1, NewRow: -1, OldColumn: -1, NewColumn: 3, New: []string{"Notes", ""}},
+			},
+		},
+		{
+			name: "column renamed",
+			before: newTable(t,
+				[]string{"Name", "Offset"},
+				[]string{"CTRL", "0"}),
+			after: newTable(t,
+				[]string{"Name", "Address"},
+				[]string{"CTRL", "0"}),
+			wantChange: []Change{
+				{Kind: CellChanged, OldRow: 0, NewRow: 0, OldColumn: 1, NewColumn: 1, Old: []string{"Offset"}, New: []string{"Address"}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.wantChange, Diff(tc.before, tc.after)); diff != "" {
+				t.Errorf("Diff() (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	config := gridtable.Config{NumHeaderRows: 1, Columns: []gridtable.ColumnSpec{{Width: 10}, {Width: 10}}}
+	table, err := gridtable.NewTable(config, [][]*gridtable.Cell{
+		{{Text: "Heading", ColSpan: 1}, nil},
+		{{Text: "a\n  b"}, {Text: ""}},
+	})
+	if err != nil {
+		t.Fatalf("NewTable() = %v", err)
+	}
+	want := [][]string{{"Heading", ""}, {"a b", ""}}
+	if diff := cmp.Diff(want, Text(table)); diff != "" {
+		t.Errorf("Text() (-want +got):\n%v", diff)
+	}
+}